@@ -0,0 +1,78 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// An ArchiveError lists the entries of an archive that failed to load.
+type ArchiveError struct {
+	Names []string
+	Errs  []error
+}
+
+func (e *ArchiveError) Error() string {
+	msgs := make([]string, len(e.Names))
+	for i, name := range e.Names {
+		msgs[i] = fmt.Sprintf("%s: %v", name, e.Errs[i])
+	}
+	return fmt.Sprintf("Failed to load %d archive entries: %s", len(e.Names), strings.Join(msgs, "; "))
+}
+
+// Load every save file in a zip archive, in filename order. Entries that fail
+// to load are left out and reported together in an *ArchiveError, after the
+// rest have loaded.
+func LoadArchive(path string) ([]*SaveData, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+	return readArchive(&archive.Reader)
+}
+
+func readArchive(archive *zip.Reader) ([]*SaveData, error) {
+	var files []*zip.File
+	for _, file := range archive.File {
+		if !file.FileInfo().IsDir() && saveExts[path.Ext(file.Name)] {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	var saves []*SaveData
+	failed := new(ArchiveError)
+	for _, file := range files {
+		save, err := readArchiveFile(file)
+		if err != nil {
+			failed.Names = append(failed.Names, file.Name)
+			failed.Errs = append(failed.Errs, err)
+			continue
+		}
+		saves = append(saves, save)
+	}
+	if len(failed.Names) > 0 {
+		return saves, failed
+	}
+	return saves, nil
+}
+
+// Load the save data in one archive entry.
+func readArchiveFile(file *zip.File) (*SaveData, error) {
+	r, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	contents, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSave(path.Ext(file.Name), contents)
+}
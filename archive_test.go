@@ -0,0 +1,83 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Write a zip archive holding the given files to a temporary directory.
+func writeArchive(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, contents := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal("Setup error:", err)
+		}
+		if _, err := f.Write(contents); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	path := filepath.Join(t.TempDir(), "pack.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	return path
+}
+
+func TestLoadArchive(t *testing.T) {
+	jsonData, err := os.ReadFile("game.json")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	tomlData, err := os.ReadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	path := writeArchive(t, map[string][]byte{
+		"b/second.toml": tomlData,
+		"a/first.json":  jsonData,
+		"README.txt":    []byte("Not a save."),
+	})
+
+	saves, err := LoadArchive(path)
+	if err != nil {
+		t.Fatal("LoadArchive ->", err)
+	}
+	if len(saves) != 2 {
+		t.Fatalf("LoadArchive -> %d saves; expected 2", len(saves))
+	}
+	for i, name := range []string{"game.json", "game.toml"} {
+		expected, err := LoadFile(name)
+		if err != nil {
+			t.Fatal("Setup error:", err)
+		}
+		a, _ := CanonicalJSON(saves[i])
+		b, _ := CanonicalJSON(expected)
+		if !bytes.Equal(a, b) {
+			t.Errorf("LoadArchive[%d] -> %s; expected %s", i, a, b)
+		}
+	}
+
+	// Broken entries are reported without losing the rest.
+	path = writeArchive(t, map[string][]byte{
+		"good.toml": tomlData,
+		"bad.json":  []byte("{"),
+	})
+	saves, err = LoadArchive(path)
+	var archiveErr *ArchiveError
+	if !errors.As(err, &archiveErr) || len(archiveErr.Names) != 1 || archiveErr.Names[0] != "bad.json" {
+		t.Errorf("LoadArchive with a broken entry -> %v; expected an ArchiveError for bad.json", err)
+	}
+	if len(saves) != 1 {
+		t.Errorf("LoadArchive with a broken entry -> %d saves; expected 1", len(saves))
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Version of the binary save format. Version 2 adds the number of decks and
+// is only written for games with more than one deck. Version 3 adds the draw
+// count, seed and variant too, and is only written for games that have any
+// of them.
+const (
+	binaryVersion      byte = 1
+	binaryDecksVersion byte = 2
+	binaryDealVersion  byte = 3
+)
+
+// Flag set on a card byte for a known facedown card.
+const binaryKnown byte = 0x80
+
+// Flags in the variant byte of a version 3 header.
+const (
+	binaryAceHigh byte = 1 << iota
+	binaryManualFlip
+)
+
+// Encode a card code as a single byte with the suit in the high nibble and
+// the rank in the low nibble.
+func encodeCard(code string) (byte, error) {
+	code, known := splitKnown(code)
+	card, err := ParseCard(code)
+	if err != nil {
+		return 0, err
+	}
+	b := byte(card.Suit)<<4 | byte(card.Rank)
+	if known {
+		b |= binaryKnown
+	}
+	return b, nil
+}
+
+// Decode a card byte back into a card code.
+func decodeCard(b byte) (string, error) {
+	card := Card{Rank: CardRank(b & 0x0f), Suit: CardSuit(b >> 4 & 0x07)}
+	if card.Rank > UNKNOWN_RANK || card.Suit > UNKNOWN_SUIT {
+		return "", errorOf(ErrInvalidCard, "Invalid card byte: %#02x", b)
+	}
+	if b&binaryKnown != 0 {
+		return KNOWN_FACEDOWN + card.Id(), nil
+	}
+	return card.Id(), nil
+}
+
+func appendCards(data []byte, codes []string) ([]byte, error) {
+	data = binary.AppendUvarint(data, uint64(len(codes)))
+	for _, code := range codes {
+		b, err := encodeCard(code)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b)
+	}
+	return data, nil
+}
+
+// Encode save data in a compact binary form. Each card is stored as one byte.
+func (save *SaveData) MarshalBinary() ([]byte, error) {
+	var err error
+	if len(save.Tableau.Facedown) != len(save.Tableau.Stacks) {
+		return nil, errorOf(ErrInvalidTableau, "tableau.stacks and tableau.facedown lengths do not match.")
+	}
+	piles, err := save.foundationPiles()
+	if err != nil {
+		return nil, err
+	}
+	stock, pos, err := save.stockPile()
+	if err != nil {
+		return nil, err
+	}
+
+	// Header.
+	data := []byte{binaryVersion}
+	switch {
+	case save.DrawCount > 1 || save.Seed != nil || save.Variant != (Variant{}):
+		data = []byte{binaryDealVersion}
+		// Both counts treat anything below 1 as 1, so they're written as 0.
+		for _, n := range []int{save.Decks, save.DrawCount} {
+			if n < 0 {
+				n = 0
+			}
+			data = binary.AppendUvarint(data, uint64(n))
+		}
+		if save.Seed == nil {
+			data = append(data, 0)
+		} else {
+			data = binary.AppendVarint(append(data, 1), *save.Seed)
+		}
+		var flags byte
+		if save.Variant.Order == ACE_HIGH {
+			flags |= binaryAceHigh
+		}
+		if save.Variant.ManualFlip {
+			flags |= binaryManualFlip
+		}
+		data = append(data, flags)
+	case save.decks() > 1:
+		data = []byte{binaryDecksVersion}
+		data = binary.AppendUvarint(data, uint64(save.Decks))
+	}
+	data = binary.AppendVarint(data, int64(save.Stock.Limit))
+	data = binary.AppendVarint(data, int64(save.Stock.Loop))
+	data = binary.AppendVarint(data, int64(pos))
+	data = binary.AppendUvarint(data, uint64(len(save.Tableau.Stacks)))
+
+	// Stock.
+	if data, err = appendCards(data, stock); err != nil {
+		return nil, err
+	}
+
+	// Tableau.
+	for i, codes := range save.Tableau.Stacks {
+		data = binary.AppendVarint(data, int64(save.Tableau.Facedown[i]))
+		if data, err = appendCards(data, codes); err != nil {
+			return nil, err
+		}
+	}
+
+	// Foundations.
+	for _, codes := range piles {
+		if data, err = appendCards(data, codes); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// Reads values from binary save data, keeping the first error encountered.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) fail(msg string) {
+	if r.err == nil {
+		r.err = errors.New(msg)
+	}
+}
+
+func (r *binaryReader) varint() int {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail("Truncated or malformed binary save data.")
+		return 0
+	}
+	r.data = r.data[n:]
+	return int(v)
+}
+
+func (r *binaryReader) uvarint() int {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 || v > uint64(len(r.data)) {
+		r.fail("Truncated or malformed binary save data.")
+		return 0
+	}
+	r.data = r.data[n:]
+	return int(v)
+}
+
+func (r *binaryReader) cards() []string {
+	size := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if size > len(r.data) {
+		r.fail("Truncated binary save data.")
+		return nil
+	}
+	codes := make([]string, size, size)
+	for i, b := range r.data[:size] {
+		code, err := decodeCard(b)
+		if err != nil {
+			r.err = err
+			return nil
+		}
+		codes[i] = code
+	}
+	r.data = r.data[size:]
+	return codes
+}
+
+// Decode save data produced by MarshalBinary.
+func (save *SaveData) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("Empty binary save data.")
+	}
+	if data[0] < binaryVersion || data[0] > binaryDealVersion {
+		return fmt.Errorf("Unsupported binary save version %d.", data[0])
+	}
+	r := &binaryReader{data: data[1:]}
+
+	// Header.
+	save.Decks, save.DrawCount, save.Seed, save.Variant = 0, 0, nil, Variant{}
+	if data[0] >= binaryDecksVersion {
+		save.Decks = r.uvarint()
+		if save.Decks > maxDecks {
+			r.fail("Too many decks in binary save data.")
+		}
+	}
+	if data[0] >= binaryDealVersion {
+		save.DrawCount = r.uvarint()
+		if r.next() == 1 {
+			seed := int64(r.varint())
+			save.Seed = &seed
+		}
+		flags := r.next()
+		if flags&binaryAceHigh != 0 {
+			save.Variant.Order = ACE_HIGH
+		}
+		save.Variant.ManualFlip = flags&binaryManualFlip != 0
+	}
+	save.Stock.Limit = r.varint()
+	save.Stock.Loop = r.varint()
+	save.Stock.Pos = r.varint()
+	tbSize := r.uvarint()
+
+	// Stock.
+	save.Stock.Stack = r.cards()
+
+	// Tableau.
+	save.Tableau.Stacks = make([][]string, tbSize, tbSize)
+	save.Tableau.Facedown = make([]int, tbSize, tbSize)
+	for i := 0; i < tbSize && r.err == nil; i++ {
+		save.Tableau.Facedown[i] = r.varint()
+		save.Tableau.Stacks[i] = r.cards()
+	}
+
+	// Foundations.
+	save.Foundations = make(map[string][]string)
+	for _, name := range foundationNames(save.decks()) {
+		save.Foundations[name] = r.cards()
+	}
+
+	if r.err == nil && len(r.data) > 0 {
+		r.fail("Trailing bytes in binary save data.")
+	}
+	return r.err
+}
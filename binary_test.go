@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	save, err := LoadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	save.Stock.Pos = 5
+	save.Stock.Loop = 1
+	save.Foundations["spades"] = []string{"sA"}
+	save.Stock.Stack = save.Stock.Stack[1 : len(save.Stock.Stack)-1]
+	save.Tableau.Stacks[0] = append(save.Tableau.Stacks[0], "d10")
+	save.Tableau.Stacks[6][1] = "~h6"
+
+	data, err := save.MarshalBinary()
+	if err != nil {
+		t.Fatal("MarshalBinary:", err)
+	}
+
+	// Write to disk to also exercise LoadFile's dispatch.
+	path := filepath.Join(t.TempDir(), "game.bin")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	loaded, err := LoadFile(path)
+	if err != nil {
+		t.Fatal("LoadFile:", err)
+	}
+
+	// Card codes are normalized by the round trip, so compare imported games.
+	var expGame, outGame Game
+	if err := expGame.Import(save); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := outGame.Import(loaded); err != nil {
+		t.Fatal("Import:", err)
+	}
+	expected, _ := json.Marshal(expGame)
+	output, _ := json.Marshal(outGame)
+	if !bytes.Equal(output, expected) {
+		t.Errorf("Binary round trip != expected:\n\noutput:   %s\n\nexpected: %s", output, expected)
+	}
+
+	// Binary form should be much smaller than JSON.
+	jsonData, _ := json.Marshal(save)
+	if len(data)*4 > len(jsonData) {
+		t.Errorf("Binary save is %d bytes; expected under a quarter of JSON's %d bytes.", len(data), len(jsonData))
+	}
+
+	// A seeded draw-three deal keeps its rules.
+	game := NewDealPreset(STANDARD_KLONDIKE, 7)
+	if data, err = game.Export().MarshalBinary(); err != nil {
+		t.Fatal("MarshalBinary:", err)
+	}
+	dealt := new(SaveData)
+	if err := dealt.UnmarshalBinary(data); err != nil {
+		t.Fatal("UnmarshalBinary:", err)
+	}
+	restored, err := NewGameFromSave(dealt)
+	if err != nil {
+		t.Fatal("Import:", err)
+	}
+	if output, expected := restored.DealInfo(), game.DealInfo(); !reflect.DeepEqual(output, expected) {
+		t.Errorf("Binary round trip DealInfo -> %+v; expected %+v", output, expected)
+	}
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	save, err := LoadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	data, err := save.MarshalBinary()
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+
+	bad := map[string][]byte{
+		"empty":     {},
+		"version":   append([]byte{binaryDealVersion + 1}, data[1:]...),
+		"truncated": data[:len(data)-3],
+		"trailing":  append(append([]byte{}, data...), 0),
+	}
+	for name, input := range bad {
+		if err := new(SaveData).UnmarshalBinary(input); err == nil {
+			t.Errorf("Expected error from %s binary data.", name)
+		}
+	}
+}
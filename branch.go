@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// A line of play kept aside from the move history.
+type branch struct {
+	prefix []*Move // Moves shared with the line it split from.
+	rest   []*Move // Moves after the split, in play order.
+}
+
+// A summary of a kept line of play.
+type BranchInfo struct {
+	Id    int  // Pass to SwitchBranch.
+	Depth int  // Moves played before the branch splits off.
+	Moves int  // Moves in the branch after the split.
+	First Move // The first move after the split.
+}
+
+// Get the whole current line of play: the moves played, then the moves left
+// to redo.
+func (game *Game) line() []*Move {
+	line := append([]*Move(nil), game.Moves.Prev...)
+	for i := len(game.Moves.Next) - 1; i >= 0; i-- {
+		line = append(line, game.Moves.Next[i])
+	}
+	return line
+}
+
+// Keep the moves left to redo as a branch off the current position.
+func (game *Game) keepBranch() {
+	b := &branch{prefix: append([]*Move(nil), game.Moves.Prev...)}
+	for i := len(game.Moves.Next) - 1; i >= 0; i-- {
+		b.rest = append(b.rest, game.Moves.Next[i])
+	}
+	game.Moves.branches = append(game.Moves.branches, b)
+}
+
+// List the lines of play set aside by playing a new move after undoing.
+func (game *Game) Branches() []BranchInfo {
+	infos := make([]BranchInfo, len(game.Moves.branches))
+	for id, b := range game.Moves.branches {
+		infos[id] = BranchInfo{id, len(b.prefix), len(b.rest), *b.rest[0]}
+	}
+	return infos
+}
+
+// Move to the point where a branch splits off and make its moves the ones to
+// redo. The line being left is kept as a branch under the same id, so
+// switching twice returns to it. If the line being left has no moves past the
+// split, nothing is kept and later branches' ids move down by one. Observers
+// are told about each move taken back and played along the way.
+func (game *Game) SwitchBranch(id int) error {
+	if id < 0 || id >= len(game.Moves.branches) {
+		return fmt.Errorf("Branch %d does not exist; there are %d branches.", id, len(game.Moves.branches))
+	}
+	b := game.Moves.branches[id]
+	target := append(append([]*Move(nil), b.prefix...), b.rest...)
+	line := game.line()
+	common := 0
+	for common < len(line) && common < len(target) && line[common] == target[common] {
+		common++
+	}
+	if common > len(b.prefix) {
+		common = len(b.prefix)
+	}
+
+	// Take back moves off the branch's line, then play its moves up to the
+	// split. Moves shared with the branch may still be left to redo.
+	undone := 0
+	for size := len(game.Moves.Prev); size > common; size-- {
+		game.unapply(game.Moves.Prev[size-1])
+		game.Moves.Prev = game.Moves.Prev[:size-1]
+		undone++
+	}
+	var played []Move
+	for _, m := range target[len(game.Moves.Prev):len(b.prefix)] {
+		if err := game.apply(m); err != nil {
+			log.Panicln("Branch replayed an illegal move:", err)
+		}
+		game.Moves.Prev = append(game.Moves.Prev, m)
+		played = append(played, *m)
+	}
+	game.Moves.Next = nil
+	for i := len(b.rest) - 1; i >= 0; i-- {
+		game.Moves.Next = append(game.Moves.Next, b.rest[i])
+	}
+
+	game.Moves.branches[id] = &branch{append([]*Move(nil), line[:common]...), line[common:]}
+	if len(line) == common {
+		game.Moves.branches = append(game.Moves.branches[:id], game.Moves.branches[id+1:]...)
+	}
+
+	for ; undone > 0; undone-- {
+		game.notifyUndo()
+	}
+	game.notifyMoves(played)
+	return nil
+}
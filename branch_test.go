@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBranches(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if err := game.MoveTableau(3, 1, 6); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	drawn := stateJSON(t, game)
+
+	// Take back the draw and play something else instead.
+	if err := game.Undo(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.MoveTableau(0, 1, 6); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	moved := stateJSON(t, game)
+
+	branches := game.Branches()
+	if len(branches) != 1 {
+		t.Fatalf("Branches -> %d branches; expected 1", len(branches))
+	}
+	if b := branches[0]; b.Id != 0 || b.Depth != 1 || b.Moves != 1 || describeMove(b.First) != "sA s>w" {
+		t.Errorf("Branches[0] -> %+v; expected the draw after 1 move", b)
+	}
+
+	// Switch to the draw, then back to the tableau move.
+	for i, expected := range [][]byte{drawn, moved} {
+		if err := game.SwitchBranch(0); err != nil {
+			t.Fatalf("SwitchBranch(0) #%d -> %v", i+1, err)
+		}
+		if game.UndoDepth() != 1 || game.RedoDepth() != 1 {
+			t.Errorf("SwitchBranch(0) #%d -> %d to undo, %d to redo; expected 1, 1", i+1, game.UndoDepth(), game.RedoDepth())
+		}
+		if err := game.Redo(); err != nil {
+			t.Fatalf("Redo after SwitchBranch(0) #%d -> %v", i+1, err)
+		}
+		if output := stateJSON(t, game); !bytes.Equal(output, expected) {
+			t.Errorf("SwitchBranch(0) #%d ->\n%s\nexpected:\n%s", i+1, output, expected)
+		}
+		if len(game.Branches()) != 1 {
+			t.Errorf("SwitchBranch(0) #%d -> %d branches; expected 1", i+1, len(game.Branches()))
+		}
+	}
+
+	if err := game.SwitchBranch(1); err == nil {
+		t.Error("SwitchBranch(1) -> nil; expected error")
+	}
+}
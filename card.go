@@ -1,8 +1,6 @@
 package main
 
 import (
-	"errors"
-	"fmt"
 	"log"
 	"strings"
 )
@@ -66,7 +64,7 @@ func (card *Card) Id() string {
 	case UNKNOWN_SUIT:
 		code = append(code, '?')
 	default:
-		log.Panicln("Out of bounds card suit:", card.Suit)
+		log.Panicln("Out of bounds card suit:", int(card.Suit))
 	}
 
 	// Parse rank.
@@ -100,7 +98,7 @@ func (card *Card) Id() string {
 	case UNKNOWN_RANK:
 		code = append(code, '?')
 	default:
-		log.Panicln("Out of bounds card rank:", card.Rank)
+		log.Panicln("Out of bounds card rank:", int(card.Rank))
 	}
 
 	return string(code)
@@ -119,7 +117,7 @@ func SuitName(suit CardSuit) string {
 	case UNKNOWN_SUIT:
 		return "unknown suit"
 	}
-	log.Panicln("Out of bounds card suit:", suit)
+	log.Panicln("Out of bounds card suit:", int(suit))
 	return ""
 }
 
@@ -154,10 +152,26 @@ func RankName(rank CardRank) string {
 	case UNKNOWN_RANK:
 		return "unknown rank"
 	}
-	log.Panicln("Out of bounds card rank:", rank)
+	log.Panicln("Out of bounds card rank:", int(rank))
 	return ""
 }
 
+// Get the rank's name, or "?" if it's out of bounds.
+func (rank CardRank) String() string {
+	if rank < ACE || rank > UNKNOWN_RANK {
+		return "?"
+	}
+	return RankName(rank)
+}
+
+// Get the suit's name, or "?" if it's out of bounds.
+func (suit CardSuit) String() string {
+	if suit < SPADES || suit > UNKNOWN_SUIT {
+		return "?"
+	}
+	return SuitName(suit)
+}
+
 func ColorName(color CardColor) string {
 	switch color {
 	case BLACK:
@@ -171,6 +185,58 @@ func ColorName(color CardColor) string {
 	return ""
 }
 
+// Every known card, indexed by suit then rank. Cards are never modified, so
+// the same pointers are shared by every game and safe for concurrent use.
+var cardPool = func() (pool [52]*Card) {
+	for suit := SPADES; suit < UNKNOWN_SUIT; suit++ {
+		color := BLACK
+		if suit == HEARTS || suit == DIAMONDS {
+			color = RED
+		}
+		for rank := ACE; rank < UNKNOWN_RANK; rank++ {
+			pool[int(suit)*13+int(rank)] = &Card{rank, suit, color}
+		}
+	}
+	return
+}()
+
+// Get the card of a rank and suit. Both must be known; use ParseCard("??")
+// for an unknown card.
+func NewCard(rank CardRank, suit CardSuit) *Card {
+	if rank < ACE || rank >= UNKNOWN_RANK {
+		log.Panicln("Out of bounds card rank:", int(rank))
+	}
+	if suit < SPADES || suit >= UNKNOWN_SUIT {
+		log.Panicln("Out of bounds card suit:", int(suit))
+	}
+	return cardPool[int(suit)*13+int(rank)]
+}
+
+// Get all 52 cards of a standard deck, by suit then rank. The slice is new
+// each call, so it can be shuffled freely.
+func StandardCards() []*Card {
+	return append([]*Card(nil), cardPool[:]...)
+}
+
+// Get the codes of all 52 cards of a standard deck, by suit then rank.
+func StandardCardCodes() []string {
+	codes := make([]string, len(cardPool))
+	for i, card := range cardPool {
+		codes[i] = card.Id()
+	}
+	return codes
+}
+
+// Get the shared pointer for a known card. Cards with an unknown rank or suit
+// aren't interned, since two of them aren't necessarily the same card.
+func internCard(card Card) *Card {
+	if card.Rank < ACE || card.Rank >= UNKNOWN_RANK || card.Suit < SPADES || card.Suit >= UNKNOWN_SUIT {
+		unknown := card
+		return &unknown
+	}
+	return cardPool[int(card.Suit)*13+int(card.Rank)]
+}
+
 func ParseCard(code string) (*Card, error) {
 	var card Card
 	size := len(code)
@@ -197,13 +263,13 @@ func ParseCard(code string) (*Card, error) {
 		card.Suit = UNKNOWN_SUIT
 		card.Color = UNKNOWN_COLOR
 	default:
-		return nil, fmt.Errorf("Unrecognized card suit code %v in %q", suit, code)
+		return nil, errorOf(ErrInvalidCard, "Unrecognized card suit code %v in %q", suit, code)
 	}
 
 	// Get rank from second char.
 	switch size {
 	case 0, 1:
-		return nil, errors.New("Subceeds min code length (2): " + code)
+		return nil, errorOf(ErrInvalidCard, "Subceeds min code length (2): %s", code)
 	case 2:
 		switch code[1] {
 		case 'A':
@@ -235,19 +301,19 @@ func ParseCard(code string) (*Card, error) {
 		case '?':
 			card.Rank = UNKNOWN_RANK
 		default:
-			return nil, fmt.Errorf("Unrecognized card rank code %v in %q.", code[1], code)
+			return nil, errorOf(ErrInvalidCard, "Unrecognized card rank code %v in %q.", code[1], code)
 		}
 	case 3:
 		if code[1:3] == "10" {
 			card.Rank = TEN
 		} else {
-			return nil, fmt.Errorf("Unrecognized card rank code %v in %q.", code[1:3], code)
+			return nil, errorOf(ErrInvalidCard, "Unrecognized card rank code %v in %q.", code[1:3], code)
 		}
 	default:
-		return nil, errors.New("Exceeds max code length (3): " + code)
+		return nil, errorOf(ErrInvalidCard, "Exceeds max code length (3): %s", code)
 	}
 
-	return &card, nil
+	return internCard(card), nil
 }
 
 func ParseCards(codes []string) ([]*Card, error) {
@@ -262,3 +328,87 @@ func ParseCards(codes []string) ([]*Card, error) {
 	}
 	return stack, nil
 }
+
+type RankOrder int
+
+const (
+	ACE_LOW RankOrder = iota
+	ACE_HIGH
+)
+
+// Get the position of rank within order, starting from 0 for the lowest rank.
+func (order RankOrder) position(rank CardRank) int {
+	if order == ACE_HIGH {
+		if rank == ACE {
+			return int(KING)
+		}
+		return int(rank) - 1
+	}
+	return int(rank)
+}
+
+// Get the rank at a position within order.
+func (order RankOrder) rankAt(pos int) CardRank {
+	if order == ACE_HIGH {
+		if pos == int(KING) {
+			return ACE
+		}
+		return CardRank(pos + 1)
+	}
+	return CardRank(pos)
+}
+
+// Get the lowest rank in order.
+func (order RankOrder) Lowest() CardRank {
+	return order.rankAt(0)
+}
+
+// Get the highest rank in order.
+func (order RankOrder) Highest() CardRank {
+	return order.rankAt(int(KING))
+}
+
+// Get the rank above rank in order. Returns false if rank is the highest or unknown.
+func (rank CardRank) Next(order RankOrder) (CardRank, bool) {
+	if rank < ACE || rank > KING {
+		return UNKNOWN_RANK, false
+	}
+	pos := order.position(rank)
+	if pos >= int(KING) {
+		return UNKNOWN_RANK, false
+	}
+	return order.rankAt(pos + 1), true
+}
+
+// Get the rank below rank in order. Returns false if rank is the lowest or unknown.
+func (rank CardRank) Prev(order RankOrder) (CardRank, bool) {
+	if rank < ACE || rank > KING {
+		return UNKNOWN_RANK, false
+	}
+	pos := order.position(rank)
+	if pos <= 0 {
+		return UNKNOWN_RANK, false
+	}
+	return order.rankAt(pos - 1), true
+}
+
+// Get the card's full name, such as "seven of hearts".
+func (card *Card) Name() string {
+	if card.Rank == UNKNOWN_RANK || card.Suit == UNKNOWN_SUIT {
+		return "unknown card"
+	}
+	return RankName(card.Rank) + " of " + SuitName(card.Suit)
+}
+
+// Check if two cards are the same card of the deck by rank and suit, even if
+// they're separate values. Cards of unknown rank or suit, such as facedown
+// cards read from "??", never match.
+func (card *Card) SameIdentity(other *Card) bool {
+	if card == nil || other == nil {
+		return false
+	}
+	if card.Rank == UNKNOWN_RANK || card.Suit == UNKNOWN_SUIT {
+		return false
+	}
+	return card.Rank == other.Rank && card.Suit == other.Suit
+}
@@ -169,6 +169,34 @@ func TestRankName(t *testing.T) {
 	shouldPanicAll(t, RankName, bad)
 }
 
+func TestRankSuitString(t *testing.T) {
+	for rank := ACE; rank <= UNKNOWN_RANK; rank++ {
+		if output, expected := fmt.Sprint(rank), RankName(rank); output != expected {
+			t.Errorf("CardRank(%d).String() -> %q; expected %q", int(rank), output, expected)
+		}
+	}
+	for suit := SPADES; suit <= UNKNOWN_SUIT; suit++ {
+		if output, expected := fmt.Sprint(suit), SuitName(suit); output != expected {
+			t.Errorf("CardSuit(%d).String() -> %q; expected %q", int(suit), output, expected)
+		}
+	}
+	var rank CardRank
+	var suit CardSuit
+	if output := fmt.Sprintf("%v of %v", rank, suit); output != "ace of spades" {
+		t.Errorf("Zero values -> %q; expected %q", output, "ace of spades")
+	}
+	for _, rank := range []CardRank{-1, 14} {
+		if output := rank.String(); output != "?" {
+			t.Errorf("CardRank(%d).String() -> %q; expected \"?\"", int(rank), output)
+		}
+	}
+	for _, suit := range []CardSuit{-1, 5} {
+		if output := suit.String(); output != "?" {
+			t.Errorf("CardSuit(%d).String() -> %q; expected \"?\"", int(suit), output)
+		}
+	}
+}
+
 func TestColorName(t *testing.T) {
 	tests := map[CardColor]string{
 		BLACK:         "black",
@@ -248,3 +276,168 @@ func TestParseCards(t *testing.T) {
 		}
 	}
 }
+
+func TestRankNext(t *testing.T) {
+	for rank := ACE; rank < KING; rank++ {
+		if next, ok := rank.Next(ACE_LOW); !ok || next != rank+1 {
+			t.Errorf("(%s).Next(ACE_LOW) -> %d, %v; expected %d, true", RankName(rank), next, ok, rank+1)
+		}
+	}
+	if next, ok := KING.Next(ACE_LOW); ok {
+		t.Errorf("(king).Next(ACE_LOW) -> %d, true; expected false", next)
+	}
+	if next, ok := KING.Next(ACE_HIGH); !ok || next != ACE {
+		t.Errorf("(king).Next(ACE_HIGH) -> %d, %v; expected ace, true", next, ok)
+	}
+	if next, ok := ACE.Next(ACE_HIGH); ok {
+		t.Errorf("(ace).Next(ACE_HIGH) -> %d, true; expected false", next)
+	}
+	if next, ok := UNKNOWN_RANK.Next(ACE_LOW); ok {
+		t.Errorf("(unknown rank).Next(ACE_LOW) -> %d, true; expected false", next)
+	}
+}
+
+func TestRankPrev(t *testing.T) {
+	for rank := TWO; rank <= KING; rank++ {
+		if prev, ok := rank.Prev(ACE_LOW); !ok || prev != rank-1 {
+			t.Errorf("(%s).Prev(ACE_LOW) -> %d, %v; expected %d, true", RankName(rank), prev, ok, rank-1)
+		}
+	}
+	if prev, ok := ACE.Prev(ACE_LOW); ok {
+		t.Errorf("(ace).Prev(ACE_LOW) -> %d, true; expected false", prev)
+	}
+	if prev, ok := ACE.Prev(ACE_HIGH); !ok || prev != KING {
+		t.Errorf("(ace).Prev(ACE_HIGH) -> %d, %v; expected king, true", prev, ok)
+	}
+	if prev, ok := TWO.Prev(ACE_HIGH); ok {
+		t.Errorf("(two).Prev(ACE_HIGH) -> %d, true; expected false", prev)
+	}
+}
+
+func TestCardName(t *testing.T) {
+	tests := map[string]string{
+		"h7":  "seven of hearts",
+		"SA":  "ace of spades",
+		"d10": "ten of diamonds",
+		"??":  "unknown card",
+		"c?":  "unknown card",
+	}
+	for code, expected := range tests {
+		card, _ := ParseCard(code)
+		if output := card.Name(); output != expected {
+			t.Errorf("(%s).Name() -> %q; expected %q", code, output, expected)
+		}
+	}
+}
+
+func TestParseCardInterning(t *testing.T) {
+	a, _ := ParseCard("SA")
+	b, _ := ParseCard("sa")
+	if a != b {
+		t.Error("ParseCard(\"SA\") and ParseCard(\"sa\") returned different pointers.")
+	}
+	c, _ := ParseCard("d10")
+	d, _ := ParseCard("D1")
+	if c != d {
+		t.Error("ParseCard(\"d10\") and ParseCard(\"D1\") returned different pointers.")
+	}
+	if allocs := testing.AllocsPerRun(10, func() { ParseCard("HQ") }); allocs != 0 {
+		t.Errorf("ParseCard(\"HQ\") allocated %v times; expected 0", allocs)
+	}
+	// Unknown cards must stay distinct.
+	for _, code := range []string{"??", "s?", "?A"} {
+		x, _ := ParseCard(code)
+		y, _ := ParseCard(code)
+		if x == y {
+			t.Errorf("ParseCard(%q) returned the same pointer twice.", code)
+		}
+	}
+}
+
+func BenchmarkParseCards(b *testing.B) {
+	codes := []string{"SA", "H10", "DK", "C7", "S2", "HQ", "D4", "CJ"}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseCards(codes)
+	}
+}
+
+func TestSameIdentity(t *testing.T) {
+	// A facedown card uncovered later is the same card as its face-up copy.
+	game := loadTestGame(t, "game.toml")
+	hidden := game.Tableau.Stacks[6][0]
+	copied := *hidden
+	if !hidden.SameIdentity(&copied) {
+		t.Errorf("SameIdentity(%s, copy) -> false; expected true", hidden.Id())
+	}
+
+	cards := mustParseCards(t, "h7", "d7", "??")
+	tests := []struct {
+		a, b     *Card
+		expected bool
+	}{
+		{cards[0], cards[0], true},
+		{cards[0], cards[1], false},
+		{cards[2], cards[2], false},
+		{cards[0], nil, false},
+	}
+	for _, test := range tests {
+		if output := test.a.SameIdentity(test.b); output != test.expected {
+			t.Errorf("SameIdentity(%v, %v) -> %v; expected %v", test.a, test.b, output, test.expected)
+		}
+	}
+}
+
+func TestStandardCards(t *testing.T) {
+	codes := StandardCardCodes()
+	cards := StandardCards()
+	if len(codes) != 52 || len(cards) != 52 {
+		t.Fatalf("StandardCardCodes, StandardCards -> %d, %d cards; expected 52", len(codes), len(cards))
+	}
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if seen[code] {
+			t.Errorf("StandardCardCodes -> duplicate %s", code)
+		}
+		seen[code] = true
+		if card, err := ParseCard(code); err != nil || card != cards[i] {
+			t.Errorf("StandardCards[%d] -> %v; expected %s", i, cards[i], code)
+		}
+	}
+	if codes[0] != "SA" || codes[51] != "DK" {
+		t.Errorf("StandardCardCodes -> %s ... %s; expected SA ... DK", codes[0], codes[51])
+	}
+
+	// Callers may reorder their copy without affecting later calls.
+	cards[0], cards[51] = cards[51], cards[0]
+	if StandardCards()[0].Id() != "SA" {
+		t.Error("StandardCards returned a shared slice.")
+	}
+}
+
+func TestNewCard(t *testing.T) {
+	card := NewCard(ACE, SPADES)
+	if card.Id() != "SA" {
+		t.Errorf("NewCard(ACE, SPADES).Id() -> %s; expected SA", card.Id())
+	}
+	if parsed, _ := ParseCard("sa"); card != parsed {
+		t.Error("NewCard(ACE, SPADES) -> a different pointer from ParseCard(\"sa\")")
+	}
+	if card := NewCard(KING, DIAMONDS); card.Color != RED || card.Name() != "king of diamonds" {
+		t.Errorf("NewCard(KING, DIAMONDS) -> %+v; expected the red king of diamonds", *card)
+	}
+
+	for _, test := range []struct {
+		rank CardRank
+		suit CardSuit
+	}{{UNKNOWN_RANK, SPADES}, {ACE, UNKNOWN_SUIT}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewCard(%v, %v) -> no panic; expected one", test.rank, test.suit)
+				}
+			}()
+			NewCard(test.rank, test.suit)
+		}()
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Version of the search checkpoint format written by SolveResumable.
+const checkpointVersion byte = 1
+
+// Search for a win like SolveContext, picking up where an earlier call left
+// off if resume holds its checkpoint. MaxStates counts the positions expanded
+// over every run, so a resumed search ends where an uninterrupted one would.
+// If the search stops without a win, because ctx was canceled or MaxStates
+// positions were expanded, its progress is written to checkpoint so a later
+// call can continue it. Either reader or writer may be nil. A resumed search
+// keeps the MaxTableEntries it started with.
+func (game *Game) SolveResumable(ctx context.Context, opts SolveOptions, resume io.Reader, checkpoint io.Writer) ([]Move, bool, error) {
+	if opts.MaxStates == 0 {
+		opts.MaxStates = defaultSolveStates
+	}
+	work := game.searchClone()
+	start := work.stateKey()
+	state := work.newSearch(opts, solveWeight)
+	if resume != nil {
+		data, err := io.ReadAll(resume)
+		if err != nil {
+			return nil, false, err
+		}
+		if state, err = unmarshalSearch(data, start); err != nil {
+			return nil, false, err
+		}
+	}
+	solution, ok, err := work.runSearch(ctx, opts, solveWeight, state)
+	// A search that found a bad node isn't worth continuing.
+	if !ok && checkpoint != nil && (err == nil || err == ctx.Err()) {
+		if _, werr := checkpoint.Write(state.marshal(start)); werr != nil {
+			return nil, false, werr
+		}
+	}
+	return solution, ok, err
+}
+
+// Encode a move for a checkpoint.
+func appendMove(data []byte, m *Move) []byte {
+	data = binary.AppendVarint(data, int64(m.From.Category))
+	data = binary.AppendVarint(data, int64(m.From.Stack))
+	data = binary.AppendVarint(data, int64(m.From.Index))
+	data = binary.AppendVarint(data, int64(m.To.Category))
+	data = binary.AppendVarint(data, int64(m.To.Stack))
+	if m.Card == nil {
+		return append(data, 0)
+	}
+	// The card byte is laid out as in encodeCard.
+	return append(data, 1, byte(m.Card.Suit)<<4|byte(m.Card.Rank))
+}
+
+// Encode the search's progress, tagged with the key of the position it
+// started from. Nodes are listed parents first, so each can refer back to its
+// parent by number.
+func (s *searchState) marshal(start string) []byte {
+	data := []byte{checkpointVersion}
+	data = binary.AppendUvarint(data, uint64(len(start)))
+	data = append(data, start...)
+	for _, n := range []int{s.stats.states, s.stats.branches, s.stats.maxDepth, s.stats.tableHits} {
+		data = binary.AppendVarint(data, int64(n))
+	}
+
+	// Positions seen.
+	keys := s.seen.keys()
+	data = binary.AppendVarint(data, int64(s.seen.limit))
+	data = binary.AppendUvarint(data, uint64(len(keys)))
+	for _, key := range keys {
+		data = binary.AppendUvarint(data, uint64(len(key)))
+		data = append(data, key...)
+	}
+
+	// Nodes, numbered from 1 so 0 can mean no parent.
+	number := make(map[*searchNode]int)
+	var nodes []*searchNode
+	var visit func(n *searchNode) int
+	visit = func(n *searchNode) int {
+		if n == nil {
+			return 0
+		}
+		if i, ok := number[n]; ok {
+			return i
+		}
+		visit(n.parent)
+		nodes = append(nodes, n)
+		number[n] = len(nodes)
+		return len(nodes)
+	}
+	frontier := make([]int, len(*s.frontier))
+	for i, n := range *s.frontier {
+		frontier[i] = visit(n)
+	}
+	data = binary.AppendUvarint(data, uint64(len(nodes)))
+	for _, n := range nodes {
+		data = binary.AppendVarint(data, int64(number[n.parent]))
+		if n.move == nil {
+			data = append(data, 0)
+		} else {
+			data = appendMove(append(data, 1), n.move)
+		}
+		data = binary.AppendVarint(data, int64(n.depth))
+		data = binary.AppendVarint(data, int64(n.score))
+	}
+
+	// Frontier, in heap order.
+	data = binary.AppendUvarint(data, uint64(len(frontier)))
+	for _, i := range frontier {
+		data = binary.AppendVarint(data, int64(i))
+	}
+	return data
+}
+
+// Read one byte.
+func (r *binaryReader) next() byte {
+	if r.err != nil {
+		return 0
+	}
+	if len(r.data) == 0 {
+		r.fail("Truncated binary data.")
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+// Read a count of items, each taking at least one byte, failing if there
+// aren't that many bytes left.
+func (r *binaryReader) count() int {
+	size := r.uvarint()
+	if r.err == nil && size > len(r.data) {
+		r.fail("Truncated binary data.")
+	}
+	if r.err != nil {
+		return 0
+	}
+	return size
+}
+
+// Read a length and that many bytes.
+func (r *binaryReader) bytes() string {
+	size := r.count()
+	if r.err != nil {
+		return ""
+	}
+	b := string(r.data[:size])
+	r.data = r.data[size:]
+	return b
+}
+
+// Decode a move written by appendMove.
+func (r *binaryReader) move() *Move {
+	m := new(Move)
+	m.From.Category, m.From.Stack, m.From.Index = r.varint(), r.varint(), r.varint()
+	m.To.Category, m.To.Stack = r.varint(), r.varint()
+	if r.next() == 1 {
+		code, err := decodeCard(r.next())
+		if err != nil && r.err == nil {
+			r.err = err
+		}
+		if m.Card, err = ParseCard(code); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+	return m
+}
+
+// Decode a search written by marshal. Fails if it started from a position
+// other than start.
+func unmarshalSearch(data []byte, start string) (*searchState, error) {
+	if len(data) == 0 {
+		return nil, errors.New("Empty search checkpoint.")
+	}
+	if data[0] != checkpointVersion {
+		return nil, fmt.Errorf("Unsupported search checkpoint version %d.", data[0])
+	}
+	r := &binaryReader{data: data[1:]}
+	if key := r.bytes(); r.err == nil && key != start {
+		return nil, errors.New("Search checkpoint is for a different position.")
+	}
+	s := new(searchState)
+	s.stats.states, s.stats.branches = r.varint(), r.varint()
+	s.stats.maxDepth, s.stats.tableHits = r.varint(), r.varint()
+
+	s.seen = NewBoundedStateSet(r.varint())
+	for i, size := 0, r.count(); i < size && r.err == nil; i++ {
+		s.seen.addKey(r.bytes())
+	}
+
+	nodes := make([]*searchNode, r.count())
+	for i := range nodes {
+		if r.err != nil {
+			break
+		}
+		n := new(searchNode)
+		if parent := r.varint(); parent < 0 || parent > i {
+			r.fail("Search checkpoint node refers to a later parent.")
+		} else if parent > 0 {
+			n.parent = nodes[parent-1]
+		}
+		if r.next() == 1 {
+			n.move = r.move()
+		}
+		n.depth, n.score = r.varint(), r.varint()
+		switch {
+		case n.parent == nil && (n.move != nil || n.depth != 0),
+			n.parent != nil && (n.move == nil || n.depth != n.parent.depth+1):
+			r.fail("Search checkpoint node doesn't follow from its parent.")
+		}
+		nodes[i] = n
+	}
+
+	frontier := make(nodeHeap, r.count())
+	for i := range frontier {
+		if j := r.varint(); j < 1 || j > len(nodes) {
+			r.fail("Search checkpoint frontier refers to a missing node.")
+		} else {
+			frontier[i] = nodes[j-1]
+		}
+	}
+	s.frontier = &frontier
+
+	if r.err == nil && len(r.data) > 0 {
+		r.fail("Trailing bytes in search checkpoint.")
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return s, nil
+}
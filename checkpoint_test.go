@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSolveResumable(t *testing.T) {
+	// Deal 2 takes the solver thousands of positions.
+	game := NewDeal(2)
+	ctx := context.Background()
+	opts := SolveOptions{MaxStates: 100000}
+	expected, ok, err := game.SolveResumable(ctx, opts, nil, nil)
+	if err != nil || !ok {
+		t.Fatal("Setup error: uninterrupted SolveResumable ->", ok, err)
+	}
+
+	// Stop after 1,000 positions, resume to 2,000, and then to the end.
+	var first, second bytes.Buffer
+	if _, ok, err := game.SolveResumable(ctx, SolveOptions{MaxStates: 1000}, nil, &first); ok || err != nil {
+		t.Fatalf("SolveResumable(1000 states) -> %v, %v; expected no solution yet", ok, err)
+	}
+	if _, ok, err := game.SolveResumable(ctx, SolveOptions{MaxStates: 2000}, &first, &second); ok || err != nil {
+		t.Fatalf("Resumed SolveResumable(2000 states) -> %v, %v; expected no solution yet", ok, err)
+	}
+	solution, ok, err := game.SolveResumable(ctx, opts, &second, nil)
+	if err != nil || !ok {
+		t.Fatalf("Resumed SolveResumable -> %v, %v; expected a solution", ok, err)
+	}
+	var output, want []string
+	for _, m := range solution {
+		output = append(output, describeMove(m))
+	}
+	for _, m := range expected {
+		want = append(want, describeMove(m))
+	}
+	if !reflect.DeepEqual(output, want) {
+		t.Errorf("Resumed SolveResumable -> %v; expected %v", output, want)
+	}
+	assertSolves(t, game, solution)
+
+	// A checkpoint only resumes the position it was taken from.
+	var other bytes.Buffer
+	if _, _, err := game.SolveResumable(ctx, SolveOptions{MaxStates: 10}, nil, &other); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if _, _, err := NewDeal(3).SolveResumable(ctx, opts, &other, nil); err == nil {
+		t.Error("Expected error resuming a checkpoint on another deal.")
+	}
+	if _, _, err := game.SolveResumable(ctx, opts, bytes.NewReader(other.Bytes()[:other.Len()/2]), nil); err == nil {
+		t.Error("Expected error resuming a truncated checkpoint.")
+	}
+}
+
+func TestSearchCheckpoint(t *testing.T) {
+	work := NewDeal(2).searchClone()
+	start := work.stateKey()
+	opts := SolveOptions{MaxStates: 500}
+	state := work.newSearch(opts, solveWeight)
+	if _, ok, err := work.runSearch(context.Background(), opts, solveWeight, state); ok || err != nil {
+		t.Fatal("Setup error: runSearch ->", ok, err)
+	}
+
+	output, err := unmarshalSearch(state.marshal(start), start)
+	if err != nil {
+		t.Fatal("unmarshalSearch ->", err)
+	}
+	if output.stats != state.stats {
+		t.Errorf("unmarshalSearch stats -> %+v; expected %+v", output.stats, state.stats)
+	}
+	if output.seen.Len() != state.seen.Len() {
+		t.Errorf("unmarshalSearch seen -> %d positions; expected %d", output.seen.Len(), state.seen.Len())
+	}
+	if len(*output.frontier) != len(*state.frontier) {
+		t.Fatalf("unmarshalSearch frontier -> %d nodes; expected %d", len(*output.frontier), len(*state.frontier))
+	}
+	for i, n := range *state.frontier {
+		got := (*output.frontier)[i]
+		if got.score != n.score || got.depth != n.depth {
+			t.Errorf("Frontier node %d -> score %d, depth %d; expected %d, %d", i, got.score, got.depth, n.score, n.depth)
+		}
+		for j, m := range n.path() {
+			if describeMove(*got.path()[j]) != describeMove(*m) {
+				t.Errorf("Frontier node %d move %d -> %s; expected %s", i, j, describeMove(*got.path()[j]), describeMove(*m))
+			}
+		}
+	}
+}
+
+func TestSearchCheckpointIllegalMove(t *testing.T) {
+	game := NewDeal(2)
+	work := game.searchClone()
+	start := work.stateKey()
+	state := work.newSearch(SolveOptions{}, solveWeight)
+	root := (*state.frontier)[0]
+	illegal := new(Move)
+	illegal.From.Category = FOUNDATION
+	illegal.To.Category = TABLEAU
+	state.frontier = &nodeHeap{{move: illegal, parent: root, depth: 1}}
+
+	var checkpoint bytes.Buffer
+	resume := bytes.NewReader(state.marshal(start))
+	if _, ok, err := game.SolveResumable(context.Background(), SolveOptions{MaxStates: 50}, resume, &checkpoint); ok || err == nil {
+		t.Errorf("SolveResumable with an illegal move in the checkpoint -> %v, %v; expected an error", ok, err)
+	}
+	if checkpoint.Len() != 0 {
+		t.Errorf("SolveResumable with an illegal move in the checkpoint wrote %d bytes; expected none", checkpoint.Len())
+	}
+}
+
+func TestSearchCheckpointTruncated(t *testing.T) {
+	var checkpoint bytes.Buffer
+	game := NewDeal(2)
+	if _, _, err := game.SolveResumable(context.Background(), SolveOptions{MaxStates: 50}, nil, &checkpoint); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	data := checkpoint.Bytes()
+	for size := 0; size < len(data); size++ {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("SolveResumable with %d of %d checkpoint bytes panicked: %v", size, len(data), r)
+				}
+			}()
+			if _, _, err := game.SolveResumable(context.Background(), SolveOptions{MaxStates: 50}, bytes.NewReader(data[:size]), nil); err == nil {
+				t.Errorf("SolveResumable with %d of %d checkpoint bytes -> nil; expected error", size, len(data))
+			}
+		}()
+	}
+}
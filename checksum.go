@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Extension of the sidecar file holding a save file's checksum. The sidecar
+// uses the sha256sum format, so it can also be checked with that tool.
+const CHECKSUM_EXT = ".sha256"
+
+// Write the checksum sidecar for a save file's contents.
+func writeChecksum(path string, contents []byte) error {
+	sum := sha256.Sum256(contents)
+	line := fmt.Sprintf("%s  %s\n", hex.EncodeToString(sum[:]), filepath.Base(path))
+	return os.WriteFile(path+CHECKSUM_EXT, []byte(line), 0o644)
+}
+
+// Remove a save file's checksum sidecar, if it has one, so a stale checksum
+// isn't left behind for a file saved without one.
+func removeChecksum(path string) error {
+	if err := os.Remove(path + CHECKSUM_EXT); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Check a save file's contents against its checksum sidecar. Files without a
+// sidecar pass.
+func verifyChecksum(path string, contents []byte) error {
+	line, err := os.ReadFile(path + CHECKSUM_EXT)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return errorOf(ErrChecksum, "Checksum file for %s is empty.", path)
+	}
+	sum := sha256.Sum256(contents)
+	if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+		return errorOf(ErrChecksum, "Checksum mismatch for %s; the file has changed since it was saved.", path)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveFileChecksum(t *testing.T) {
+	save := NewDeal(1).Export()
+	dir := t.TempDir()
+	for _, name := range []string{"game.json", "game.toml", "game.bin"} {
+		path := filepath.Join(dir, name)
+		if err := SaveFile(save, path, true); err != nil {
+			t.Fatalf("SaveFile(%s) -> %v", name, err)
+		}
+		if _, err := LoadFile(path); err != nil {
+			t.Errorf("LoadFile(%s) -> %v; expected nil", name, err)
+		}
+
+		// Corrupt one byte.
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal("Setup error:", err)
+		}
+		contents[len(contents)/2] ^= 1
+		if err := os.WriteFile(path, contents, 0o644); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+		if _, err := LoadFile(path); !errors.Is(err, ErrChecksum) {
+			t.Errorf("LoadFile(corrupted %s) -> %v; expected ErrChecksum", name, err)
+		}
+	}
+
+	// Without a sidecar, files load as before.
+	path := filepath.Join(dir, "plain.json")
+	if err := SaveFile(save, path, false); err != nil {
+		t.Fatal("SaveFile(plain.json) ->", err)
+	}
+	if _, err := os.Stat(path + CHECKSUM_EXT); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("SaveFile without checksum -> sidecar stat %v; expected not exist", err)
+	}
+	if _, err := LoadFile(path); err != nil {
+		t.Errorf("LoadFile(plain.json) -> %v; expected nil", err)
+	}
+
+	// Saving again without a checksum removes the old sidecar.
+	path = filepath.Join(dir, "resaved.json")
+	if err := SaveFile(save, path, true); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := SaveFile(NewDeal(2).Export(), path, false); err != nil {
+		t.Fatal("SaveFile(resaved.json) ->", err)
+	}
+	if _, err := os.Stat(path + CHECKSUM_EXT); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("SaveFile without checksum over a checksummed save -> sidecar stat %v; expected not exist", err)
+	}
+	if _, err := LoadFile(path); err != nil {
+		t.Errorf("LoadFile(resaved.json) -> %v; expected nil", err)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// A DealPattern describes how many cards are dealt to each tableau column and
+// how many of those are facedown. Cards left over are dealt to the stock.
+type DealPattern struct {
+	Cards    []int
+	Facedown []int
+}
+
+// The standard Klondike deal: 1 to 7 cards per column with only the top card
+// face up.
+var StandardDeal = DealPattern{
+	Cards:    []int{1, 2, 3, 4, 5, 6, 7},
+	Facedown: []int{0, 1, 2, 3, 4, 5, 6},
+}
+
+// Check that the pattern can be dealt from a single deck into a position that
+// Import accepts.
+func (p DealPattern) Validate() error {
+	size := len(p.Cards)
+	if size == 0 {
+		return fmt.Errorf("Deal pattern has no columns.")
+	}
+	if size > 7 {
+		return fmt.Errorf("Deal pattern has %d columns; max is 7.", size)
+	}
+	if len(p.Facedown) != size {
+		return fmt.Errorf("Deal pattern has %d column sizes but %d facedown counts.", size, len(p.Facedown))
+	}
+	total, facedown := 0, 0
+	for i, cards := range p.Cards {
+		if cards < 0 {
+			return fmt.Errorf("Deal pattern column %d has negative size %d.", i, cards)
+		}
+		if fd := p.Facedown[i]; fd < 0 || (fd >= cards && fd > 0) {
+			return fmt.Errorf("Deal pattern column %d is invalid: %d cards; %d facedown.", i, cards, fd)
+		}
+		total += cards
+		facedown += p.Facedown[i]
+	}
+	if total > 52 {
+		return fmt.Errorf("Deal pattern needs %d cards; a deck has 52.", total)
+	}
+	if facedown > maxFacedown {
+		return fmt.Errorf("Deal pattern has %d facedown cards; max is %d.", facedown, maxFacedown)
+	}
+	return nil
+}
+
+// Deal a new standard game from a shuffled deck. The same seed always deals
+// the same game.
+func NewDeal(seed int64) *Game {
+	game, err := NewDealPattern(seed, StandardDeal)
+	if err != nil {
+		log.Panicln(err)
+	}
+	return game
+}
+
+// Deal a new game using a custom deal pattern.
+func NewDealPattern(seed int64, p DealPattern) (*Game, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	deck := StandardCards()
+	rng := rand.New(rand.NewSource(seed))
+	rng.Shuffle(len(deck), func(i, j int) {
+		deck[i], deck[j] = deck[j], deck[i]
+	})
+
+	game := new(Game)
+	game.setColumnCount(len(p.Cards))
+	for col, size := range p.Cards {
+		game.Tableau.Stacks[col] = deck[:size:size]
+		game.Tableau.Facedown[col] = p.Facedown[col]
+		deck = deck[size:]
+	}
+	game.Stock.Stack = deck
+	game.Seed = &seed
+	return game, nil
+}
+
+// Number of seeds NewWinnableDeal tries before giving up.
+const winnableDealTries = 100
+
+// Deal the first game the solver can win, starting from seed and counting up.
+// Returns the game and the seed it was dealt from.
+func NewWinnableDeal(seed int64, maxStatesPerTry int) (*Game, int64, error) {
+	for i := int64(0); i < winnableDealTries; i++ {
+		game := NewDeal(seed + i)
+		if _, ok := game.Solve(maxStatesPerTry); ok {
+			return game, seed + i, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("No winnable deal found in %d seeds from %d.", winnableDealTries, seed)
+}
+
+// Deal and solve a standard game for each seed, counting how many the solver
+// wins within maxStates positions. Seeds are solved in parallel, one worker per
+// CPU.
+func SolvabilityStats(seeds []int64, maxStates int) (solvable, total int) {
+	jobs := make(chan int64)
+	results := make(chan bool)
+	var wg sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seed := range jobs {
+				_, ok := NewDeal(seed).Solve(maxStates)
+				results <- ok
+			}
+		}()
+	}
+	go func() {
+		for _, seed := range seeds {
+			jobs <- seed
+		}
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	for ok := range results {
+		total++
+		if ok {
+			solvable++
+		}
+	}
+	return solvable, total
+}
+
+// The rules and origin of a game, for showing its settings.
+type DealInfo struct {
+	Variant   Variant
+	DrawCount int    // Cards turned per draw.
+	Limit     int    // Passes allowed through the stock; 0 means unlimited.
+	Decks     int    // Number of decks in play.
+	Seed      *int64 // Seed the game was dealt from, or nil if unknown.
+}
+
+// Describe the game's rules and where it was dealt from.
+func (game *Game) DealInfo() DealInfo {
+	return DealInfo{game.Variant, game.drawCount(), game.Stock.Limit, game.decks(), game.Seed}
+}
+
+// Common Klondike rule combinations.
+type Preset int
+
+const (
+	// Draw one card at a time with unlimited passes through the stock.
+	EASY_KLONDIKE Preset = iota
+	// Draw three cards at a time with unlimited passes through the stock.
+	STANDARD_KLONDIKE
+	// Draw one card at a time with a single pass through the stock.
+	VEGAS_DRAW_1
+	// Draw three cards at a time with three passes through the stock.
+	VEGAS_DRAW_3
+)
+
+// Deal a new standard game configured with a preset's rules.
+func NewDealPreset(p Preset, seed int64) *Game {
+	game := NewDeal(seed)
+	switch p {
+	case EASY_KLONDIKE:
+		game.DrawCount, game.Stock.Limit = 1, 0
+	case STANDARD_KLONDIKE:
+		game.DrawCount, game.Stock.Limit = 3, 0
+	case VEGAS_DRAW_1:
+		game.DrawCount, game.Stock.Limit = 1, 1
+	case VEGAS_DRAW_3:
+		game.DrawCount, game.Stock.Limit = 3, 3
+	default:
+		log.Panicln("Out of bounds preset:", p)
+	}
+	return game
+}
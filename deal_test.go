@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestNewDeal(t *testing.T) {
+	game := NewDeal(1)
+	for col := 0; col < 7; col++ {
+		if size := len(game.Tableau.Stacks[col]); size != col+1 {
+			t.Errorf("Column %d has %d cards; expected %d", col, size, col+1)
+		}
+		if fd := game.Tableau.Facedown[col]; fd != col {
+			t.Errorf("Column %d has %d facedown cards; expected %d", col, fd, col)
+		}
+	}
+	if size := len(game.Stock.Stack); size != 24 {
+		t.Errorf("Stock has %d cards; expected 24", size)
+	}
+	assertFullDeck(t, game)
+
+	// Same seed deals the same game.
+	if !bytes.Equal(stateJSON(t, NewDeal(1)), stateJSON(t, game)) {
+		t.Error("NewDeal(1) dealt different games.")
+	}
+	if bytes.Equal(stateJSON(t, NewDeal(2)), stateJSON(t, game)) {
+		t.Error("NewDeal(1) and NewDeal(2) dealt the same game.")
+	}
+}
+
+func TestNewDealPattern(t *testing.T) {
+	// Easthaven deals three cards to each column with two facedown.
+	pattern := DealPattern{
+		Cards:    []int{3, 3, 3, 3, 3, 3, 3},
+		Facedown: []int{2, 2, 2, 2, 2, 2, 2},
+	}
+	game, err := NewDealPattern(7, pattern)
+	if err != nil {
+		t.Fatal("NewDealPattern:", err)
+	}
+	for col := 0; col < 7; col++ {
+		if size := len(game.Tableau.Stacks[col]); size != 3 {
+			t.Errorf("Column %d has %d cards; expected 3", col, size)
+		}
+		if fd := game.Tableau.Facedown[col]; fd != 2 {
+			t.Errorf("Column %d has %d facedown cards; expected 2", col, fd)
+		}
+	}
+	if size := len(game.Stock.Stack); size != 31 {
+		t.Errorf("Stock has %d cards; expected 31", size)
+	}
+	assertFullDeck(t, game)
+
+	bad := []DealPattern{
+		{Cards: []int{1, 2, 3, 4, 5, 6, 7, 8}, Facedown: []int{0, 0, 0, 0, 0, 0, 0, 0}},
+		{Cards: []int{1, 2}, Facedown: []int{0}},
+		{Cards: []int{1, 2}, Facedown: []int{0, 2}},
+		{Cards: []int{-1}, Facedown: []int{0}},
+		{},
+		{Cards: []int{13, 13, 13, 14}, Facedown: []int{0, 0, 0, 0}},
+		{Cards: []int{7, 7, 7, 7, 7, 7, 7}, Facedown: []int{6, 6, 6, 6, 6, 6, 6}},
+	}
+	for i, b := range bad {
+		if _, err := NewDealPattern(7, b); err == nil {
+			t.Errorf("Expected error from bad[%d] pattern.", i)
+		}
+	}
+}
+
+func TestNewDealPatternColumns(t *testing.T) {
+	pattern := DealPattern{
+		Cards:    []int{1, 2, 3, 4, 5},
+		Facedown: []int{0, 1, 2, 3, 4},
+	}
+	game, err := NewDealPattern(7, pattern)
+	if err != nil {
+		t.Fatal("NewDealPattern:", err)
+	}
+	if count := game.ColumnCount(); count != 5 {
+		t.Errorf("ColumnCount -> %d; expected 5", count)
+	}
+	assertFullDeck(t, game)
+	var illegal *IllegalMoveError
+	for _, col := range []int{5, 6} {
+		err := game.MoveTableau(4, 1, col)
+		if !errors.As(err, &illegal) || illegal.Reason != NO_SUCH_PILE {
+			t.Errorf("MoveTableau(4, 1, %d) -> %v; expected NO_SUCH_PILE", col, err)
+		}
+	}
+	for _, m := range game.LegalMoves() {
+		if m.From.Category == TABLEAU && m.From.Stack >= 5 || m.To.Category == TABLEAU && m.To.Stack >= 5 {
+			t.Errorf("LegalMoves -> %s; expected only columns 0 to 4", describeMove(m))
+		}
+	}
+
+	// The column count survives a save.
+	save := game.Export()
+	if size := len(save.Tableau.Stacks); size != 5 {
+		t.Errorf("Export -> %d stacks; expected 5", size)
+	}
+	loaded, err := NewGameFromSave(save)
+	if err != nil {
+		t.Fatal("Import:", err)
+	}
+	if count := loaded.ColumnCount(); count != 5 {
+		t.Errorf("Import(Export()) ColumnCount -> %d; expected 5", count)
+	}
+	if full := NewDeal(7); full.ColumnCount() != 7 || len(full.Export().Tableau.Stacks) != 7 {
+		t.Errorf("NewDeal ColumnCount -> %d; expected 7", full.ColumnCount())
+	}
+}
+
+// Check a game holds all 52 cards exactly once.
+func assertFullDeck(t *testing.T, game *Game) {
+	t.Helper()
+	r := NewRegister()
+	piles := [][]*Card{game.Stock.Stack}
+	piles = append(piles, game.Tableau.Stacks[:]...)
+	piles = append(piles, game.Foundations[:]...)
+	for _, pile := range piles {
+		for _, card := range pile {
+			if _, err := r.AddCard(card.Id()); err != nil {
+				t.Errorf("Card %s: %v", card.Id(), err)
+			}
+		}
+	}
+	if r.Total != 52 {
+		t.Errorf("Game has %d cards; expected 52", r.Total)
+	}
+}
+
+func TestNewDealPreset(t *testing.T) {
+	tests := map[Preset][2]int{
+		EASY_KLONDIKE:     {1, 0},
+		STANDARD_KLONDIKE: {3, 0},
+		VEGAS_DRAW_1:      {1, 1},
+		VEGAS_DRAW_3:      {3, 3},
+	}
+	for preset, expected := range tests {
+		game := NewDealPreset(preset, 1)
+		if game.DrawCount != expected[0] || game.Stock.Limit != expected[1] {
+			t.Errorf("NewDealPreset(%d) -> DrawCount %d, Limit %d; expected %d, %d",
+				preset, game.DrawCount, game.Stock.Limit, expected[0], expected[1])
+		}
+	}
+
+	// Easy Klondike recycles the stock without limit.
+	game := NewDealPreset(EASY_KLONDIKE, 1)
+	var recycle Move
+	recycle.From.Category = WASTE
+	recycle.To.Category = STOCK
+	for loop := 0; loop < 5; loop++ {
+		for game.Stock.Pos < len(game.Stock.Stack) {
+			if err := game.Draw(); err != nil {
+				t.Fatal("Draw:", err)
+			}
+		}
+		if err := game.Apply(recycle); err != nil {
+			t.Fatalf("Recycle on loop %d: %v", loop, err)
+		}
+	}
+
+	shouldPanic(t, func() string {
+		NewDealPreset(Preset(-1), 1)
+		return "Failed to panic: NewDealPreset(-1)"
+	})
+}
+
+func TestDealInfo(t *testing.T) {
+	game := NewDealPreset(VEGAS_DRAW_3, 7)
+	info := game.DealInfo()
+	if info.DrawCount != 3 || info.Limit != 3 || info.Decks != 1 || info.Seed == nil || *info.Seed != 7 {
+		t.Errorf("DealInfo() -> %+v; expected draw 3, limit 3, 1 deck, seed 7", info)
+	}
+
+	// The settings survive a save and load.
+	loaded, err := NewGameFromSave(game.Export())
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if reloaded := loaded.DealInfo(); reloaded.DrawCount != 3 || reloaded.Limit != 3 ||
+		reloaded.Seed == nil || *reloaded.Seed != 7 {
+		t.Errorf("DealInfo() after Import -> %+v; expected %+v", reloaded, info)
+	}
+}
+
+func TestSolvabilityStats(t *testing.T) {
+	seeds := []int64{1, 2, 3, 4, 5, 6}
+	expected := 0
+	for _, seed := range seeds {
+		if _, ok := NewDeal(seed).Solve(5000); ok {
+			expected++
+		}
+	}
+	for run := 1; run <= 2; run++ {
+		if solvable, total := SolvabilityStats(seeds, 5000); solvable != expected || total != len(seeds) {
+			t.Errorf("SolvabilityStats run %d -> %d of %d; expected %d of %d", run, solvable, total, expected, len(seeds))
+		}
+	}
+	if solvable, total := SolvabilityStats(nil, 5000); solvable != 0 || total != 0 {
+		t.Errorf("SolvabilityStats(nil) -> %d of %d; expected 0 of 0", solvable, total)
+	}
+}
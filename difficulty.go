@@ -0,0 +1,59 @@
+package main
+
+import "fmt"
+
+// How hard a deal is to win.
+type Rating int
+
+const (
+	EASY Rating = iota
+	MEDIUM
+	HARD
+	EXPERT
+)
+
+func (r Rating) String() string {
+	switch r {
+	case EASY:
+		return "easy"
+	case MEDIUM:
+		return "medium"
+	case HARD:
+		return "hard"
+	case EXPERT:
+		return "expert"
+	}
+	return "?"
+}
+
+// Rate how hard the game is to win from its current position by solving it.
+//
+// The rating starts from how many positions the solver explored before
+// finding a win: up to 1,000 is easy, up to 10,000 medium, up to 50,000 hard,
+// and more is expert. It goes up one level, to at most expert, if the solution
+// is longer than 135 moves or the solver found fewer than 2.5 useful moves per
+// position on average, since long games and games with few options leave
+// less room for mistakes. Returns an error if no solution was found.
+func (game *Game) Difficulty() (Rating, error) {
+	solution, stats, ok := game.solve(defaultSolveStates)
+	if !ok {
+		return EXPERT, fmt.Errorf("No solution found within %d positions; the game may be unwinnable.", defaultSolveStates)
+	}
+
+	var rating Rating
+	switch {
+	case stats.states <= 1000:
+		rating = EASY
+	case stats.states <= 10000:
+		rating = MEDIUM
+	case stats.states <= 50000:
+		rating = HARD
+	default:
+		rating = EXPERT
+	}
+	branching := float64(stats.branches) / float64(stats.states)
+	if (len(solution) > 135 || branching < 2.5) && rating < EXPERT {
+		rating++
+	}
+	return rating, nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestDifficulty(t *testing.T) {
+	game := endgame(t, []string{"sK", "hQ"}, []string{"hK", "sQ"})
+	if output, err := game.Difficulty(); err != nil || output != EASY {
+		t.Errorf("Difficulty() on endgame -> %v, %v; expected %v", output, err, EASY)
+	}
+
+	// Deal 2 takes the solver thousands of positions and a long solution.
+	if output, err := NewDeal(2).Difficulty(); err != nil || output != HARD {
+		t.Errorf("Difficulty() on deal 2 -> %v, %v; expected %v", output, err, HARD)
+	}
+
+	stuck := endgame(t, []string{"hA", "h2"})
+	stuck.Tableau.Facedown[0] = 1
+	if _, err := stuck.Difficulty(); err == nil {
+		t.Error("Expected error from Difficulty on an unwinnable game.")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors returned when parsing cards and loading games. Check for
+// them with errors.Is.
+var (
+	ErrInvalidCard       = errors.New("Invalid card.")
+	ErrDuplicateCard     = errors.New("Duplicate card.")
+	ErrTooManyCards      = errors.New("Too many cards.")
+	ErrTooManyStacks     = errors.New("Too many tableau stacks.")
+	ErrInvalidStock      = errors.New("Invalid stock.")
+	ErrInvalidTableau    = errors.New("Invalid tableau.")
+	ErrInvalidFoundation = errors.New("Invalid foundation.")
+	ErrDeckIncomplete    = errors.New("Deck incomplete.")
+	ErrChecksum          = errors.New("Checksum mismatch.")
+	ErrInvalidHistory    = errors.New("Invalid move history.")
+)
+
+// An error with its own message that still matches one of the error kinds
+// above, so existing messages stay readable.
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string {
+	return e.msg
+}
+
+func (e *kindError) Unwrap() error {
+	return e.kind
+}
+
+// Format an error message that matches kind with errors.Is.
+func errorOf(kind error, format string, args ...any) error {
+	return &kindError{kind, fmt.Sprintf(format, args...)}
+}
+
+// The rule an illegal move breaks.
+type IllegalMoveReason int
+
+const (
+	NO_SUCH_PILE           IllegalMoveReason = iota // The source or destination doesn't exist.
+	EMPTY_PILE                                      // The source pile has no cards to move.
+	FACEDOWN_CARD                                   // The card to move is facedown.
+	NOT_TOP_CARD                                    // Only the top card of the waste or a foundation can move.
+	NOT_A_RUN                                       // The cards to move aren't a valid run.
+	CARD_MISMATCH                                   // The named card isn't where the move expects it.
+	SAME_COLUMN                                     // Cards can't move onto their own column.
+	COLOR_MISMATCH                                  // Tableau cards must alternate colors.
+	RANK_GAP                                        // Cards must be built one rank apart.
+	NOT_KING_ON_EMPTY                               // Only the variant's highest rank can start an empty column.
+	NOT_ACE_ON_EMPTY                                // Only the variant's lowest rank can start a foundation.
+	WRONG_SUIT                                      // Foundations are built by suit.
+	TOO_MANY_TO_FOUNDATION                          // Only one card can move to a foundation at a time.
+	STOCK_EMPTY                                     // There are no cards left to draw.
+	CANNOT_RECYCLE                                  // The waste can't be turned back over.
+	NO_CARDS                                        // A move must take at least one card.
+	NOTHING_TO_FLIP                                 // The column's top card isn't facedown.
+)
+
+// An IllegalMoveError reports which rule a rejected move breaks.
+type IllegalMoveError struct {
+	Reason  IllegalMoveReason
+	Message string
+}
+
+func (e *IllegalMoveError) Error() string {
+	return e.Message
+}
+
+// Format an illegal move error.
+func illegalMove(reason IllegalMoveReason, format string, args ...any) error {
+	return &IllegalMoveError{reason, fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Find a pile's cards and the range of them that a forced move may take from
+// or put onto. The stock's top is its next card to draw.
+func (game *Game) forcePile(loc Location) (stack *[]*Card, top int, err error) {
+	switch loc.Category {
+	case TABLEAU:
+		if loc.Stack < 0 || loc.Stack >= game.ColumnCount() {
+			return nil, 0, fmt.Errorf("Tableau column %d does not exist.", loc.Stack)
+		}
+		stack = &game.Tableau.Stacks[loc.Stack]
+		return stack, len(*stack), nil
+	case FOUNDATION:
+		if loc.Stack < 0 || loc.Stack >= len(game.foundations()) {
+			return nil, 0, fmt.Errorf("Foundation %d does not exist.", loc.Stack)
+		}
+		stack = &game.Foundations[loc.Stack]
+		return stack, len(*stack), nil
+	case STOCK, WASTE:
+		return &game.Stock.Stack, game.Stock.Pos, nil
+	}
+	return nil, 0, fmt.Errorf("Unknown pile category %d.", loc.Category)
+}
+
+// Move the top count cards of one pile onto another without checking the
+// rules, for setting up positions in tests. Cards moved onto the tableau are
+// face up, and a column left with a facedown top card has it turned over. The
+// move isn't recorded. Returns the game's Validate error, if any.
+func (game *Game) ForceMove(from, to Location, count int) error {
+	src, i, err := game.forcePile(from)
+	if err != nil {
+		return err
+	}
+	if _, _, err := game.forcePile(to); err != nil {
+		return err
+	}
+	var cards []*Card
+	switch {
+	case from.Category == STOCK:
+		if count < 0 || i+count > len(*src) {
+			return fmt.Errorf("Stock has %d cards to draw; cannot take %d.", len(*src)-i, count)
+		}
+		cards = append(cards, (*src)[i:i+count]...)
+		*src = append((*src)[:i:i], (*src)[i+count:]...)
+	case count < 0 || count > i:
+		return fmt.Errorf("Pile has %d cards; cannot take %d.", i, count)
+	default:
+		cards = append(cards, (*src)[i-count:i]...)
+		*src = append((*src)[:i-count:i-count], (*src)[i:]...)
+		if from.Category == WASTE {
+			game.Stock.Pos -= count
+		}
+	}
+	if from.Category == TABLEAU {
+		game.flip(from.Stack)
+	}
+
+	// Find the destination again, since taking from the waste moves the
+	// stock's top.
+	dest, j, _ := game.forcePile(to)
+	*dest = append((*dest)[:j:j], append(cards, (*dest)[j:]...)...)
+	if to.Category == WASTE {
+		game.Stock.Pos += count
+	}
+	game.invalidateMoves()
+	return game.Validate()
+}
+
+func TestForceMove(t *testing.T) {
+	game := NewDeal(1)
+	var stock, waste, col0, col6 Location
+	stock.Category, waste.Category = STOCK, WASTE
+	col0.Category, col6.Category = TABLEAU, TABLEAU
+	col6.Stack = 6
+
+	// Deal three cards from the stock onto the waste, put the first column's
+	// card on top of them, and move two cards off the last column.
+	next := game.Stock.Stack[:3]
+	if err := game.ForceMove(stock, waste, 3); err != nil {
+		t.Fatal("ForceMove(stock, waste, 3):", err)
+	}
+	if game.Stock.Pos != 3 || game.Stock.Stack[2] != next[2] {
+		t.Errorf("ForceMove(stock, waste, 3) -> pos %d; expected 3 with %s on top", game.Stock.Pos, next[2].Id())
+	}
+	if err := game.ForceMove(col0, waste, 1); err != nil {
+		t.Fatal("ForceMove(col0, waste, 1):", err)
+	}
+	if err := game.ForceMove(col6, col0, 2); err != nil {
+		t.Fatal("ForceMove(col6, col0, 2):", err)
+	}
+	if len(game.Tableau.Stacks[0]) != 2 || len(game.Tableau.Stacks[6]) != 5 || game.Tableau.Facedown[6] != 4 {
+		t.Errorf("ForceMove(col6, col0, 2) -> columns of %d and %d cards, %d facedown; expected 2, 5, 4",
+			len(game.Tableau.Stacks[0]), len(game.Tableau.Stacks[6]), game.Tableau.Facedown[6])
+	}
+	if err := game.Validate(); err != nil {
+		t.Errorf("Validate() after forced moves -> %v; expected nil", err)
+	}
+	if len(game.Moves.Prev) != 0 {
+		t.Errorf("Forced moves recorded %d moves; expected none", len(game.Moves.Prev))
+	}
+
+	if err := game.ForceMove(col0, col6, 3); err == nil {
+		t.Error("ForceMove(col0, col6, 3) -> nil; expected error")
+	}
+	if err := game.ForceMove(col0, Location{TABLEAU, 7, 0}, 1); err == nil {
+		t.Error("ForceMove(col0, column 7, 1) -> nil; expected error")
+	}
+	if err := game.Validate(); err != nil {
+		t.Errorf("Validate() after rejected forced moves -> %v; expected nil", err)
+	}
+}
@@ -1,20 +1,40 @@
 package main
 
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sort"
+)
+
 const (
 	FOUNDATION int = iota
 	TABLEAU
 	STOCK
+	WASTE
 )
 
-type GlobalGame struct {
-  state  GameState
-  memories map[string][]GameMemory
-  solutions chan []*Move
-}
+// Most decks a game can be played with.
+const maxDecks = 2
 
-type GameState struct {
-	Foundations [4][]*Card
-	Stock       struct {
+// Most facedown cards the tableau can hold, as many as a standard deal.
+const maxFacedown = 21
+
+// A game in play. The piles are fixed-size arrays and nil stacks are empty
+// piles, so the zero value is an empty game, ready to Import into.
+type Game struct {
+	Variant   Variant
+	DrawCount int
+	Decks     int    // Number of decks in play; 0 means 1.
+	Seed      *int64 // Seed the game was dealt from, if known.
+	// Foundations 0 to 3 are spades, clubs, hearts, and diamonds. A second
+	// deck adds piles 4 to 7 for the same suits; they're unused otherwise.
+	Foundations [4 * maxDecks][]*Card
+	// The stock and waste share Stack. The first Pos cards have been drawn
+	// and form the waste, with Stack[Pos-1] on top; the rest are still to be
+	// drawn this pass, Stack[Pos] next. Loop counts recycles and Limit caps
+	// the passes, 0 meaning unlimited.
+	Stock struct {
 		Limit int
 		Loop  int
 		Pos   int
@@ -23,19 +43,28 @@ type GameState struct {
 	Tableau struct {
 		Facedown [7]int
 		Stacks   [7][]*Card
+		// Bit i of Known marks facedown card i of a column as known to the
+		// player, even though it hasn't been turned face up.
+		Known [7]uint32
+		// Columns in play, counted from the left; 0 means all 7. Columns
+		// past them stay empty and can't be played to.
+		Columns int
 	}
 	Moves struct {
 		Prev []*Move
 		Next []*Move
+		// Lines of play set aside when a move replaced the redo history.
+		branches []*branch
+	}
+	cache     *moveCache
+	observers []Observer
+	// Starting position cached by Timeline, with the first move it's for.
+	start struct {
+		first *Move
+		save  *SaveData
 	}
 }
 
-type GameMemory struct {
-  MoveCount int
-  NextMoves []*Move
-}
-
-
 type Move struct {
 	Card *Card
 	To   struct {
@@ -47,14 +76,333 @@ type Move struct {
 		Stack    int
 		Index    int
 	}
+	Note    string // Free-text commentary attached with Annotate.
+	flipped bool   // Whether the move turned a facedown card face up.
+}
+
+// Get the number of decks in play. Out of range counts, which Import
+// rejects, are clamped so a hand-built game can't index past the piles.
+func (game *Game) decks() int {
+	switch {
+	case game.Decks < 1:
+		return 1
+	case game.Decks > maxDecks:
+		return maxDecks
+	}
+	return game.Decks
+}
+
+// Get the foundation piles in use.
+func (game *Game) foundations() [][]*Card {
+	return game.Foundations[:4*game.decks()]
+}
+
+// Get the suit of a foundation pile.
+func pileSuit(pile int) CardSuit {
+	return CardSuit(pile % 4)
+}
+
+// A Location identifies a card's position in the game.
+// Stack is the tableau column or foundation pile and is unused for the stock
+// and waste. Index counts from the bottom of the pile; for the stock and waste
+// it indexes Stock.Stack.
+type Location struct {
+	Category int
+	Stack    int
+	Index    int
+}
+
+// Find where a face-up card currently is. Returns false if the card is
+// facedown, still in the stock, or not in the game.
+func (game *Game) Locate(card *Card) (Location, bool) {
+	if card == nil || card.Rank == UNKNOWN_RANK || card.Suit == UNKNOWN_SUIT {
+		return Location{}, false
+	}
+
+	// Search foundations.
+	for pile, stack := range game.foundations() {
+		for i, c := range stack {
+			if *c == *card {
+				return Location{FOUNDATION, pile, i}, true
+			}
+		}
+	}
+
+	// Search face-up tableau cards.
+	for col, stack := range game.Tableau.Stacks {
+		for i := game.Tableau.Facedown[col]; i < len(stack); i++ {
+			if *stack[i] == *card {
+				return Location{TABLEAU, col, i}, true
+			}
+		}
+	}
+
+	// Search waste.
+	for i, c := range game.Stock.Stack[:game.Stock.Pos] {
+		if *c == *card {
+			return Location{WASTE, 0, i}, true
+		}
+	}
+
+	return Location{}, false
 }
 
+// Get the face-up card on top of a pile; loc's Index is ignored. Returns
+// false if the pile is empty or doesn't exist, or if its top card is facedown,
+// as the stock's always is.
+func (game *Game) PileTop(loc Location) (*Card, bool) {
+	var card *Card
+	switch loc.Category {
+	case TABLEAU:
+		if loc.Stack >= 0 && loc.Stack < game.ColumnCount() && !game.awaitingFlip(loc.Stack) {
+			card = top(game.Tableau.Stacks[loc.Stack])
+		}
+	case WASTE:
+		card = game.pileTop(pileWaste)
+	case FOUNDATION:
+		if loc.Stack >= 0 && loc.Stack < len(game.foundations()) {
+			card = top(game.Foundations[loc.Stack])
+		}
+	}
+	return card, card != nil
+}
 
-func (*Game) Solve() {
-  memory = make(map[string][]GameMemory)
+// Check if the player knows the identity of card i in a tableau column: it's
+// face up or a facedown card recorded as known.
+func (game *Game) IsKnown(col, i int) bool {
+	stack := game.Tableau.Stacks[col]
+	if i < 0 || i >= len(stack) {
+		return false
+	}
+	return i >= game.Tableau.Facedown[col] || game.Tableau.Known[col]&(1<<i) != 0
 }
 
-func Play(game Game, move Move) {}
+// Make a deep copy of the game, sharing its cards. Cloning takes time in
+// proportion to the cards and moves, with every pile copied into one backing
+// array and the move history into another, so the number of allocations
+// stays fixed however the cards lie.
+func (game *Game) Clone() *Game {
+	clone := *game
+	size := len(game.Stock.Stack)
+	for _, stack := range game.Tableau.Stacks {
+		size += len(stack)
+	}
+	for _, stack := range game.Foundations {
+		size += len(stack)
+	}
+	cards := make([]*Card, 0, size)
+	// Copy a pile onto the end of cards. Its capacity is capped so appending
+	// to it moves it off the shared array rather than over the next pile.
+	copyPile := func(stack []*Card) []*Card {
+		if len(stack) == 0 {
+			return nil
+		}
+		start := len(cards)
+		cards = append(cards, stack...)
+		return cards[start:len(cards):len(cards)]
+	}
+	clone.Stock.Stack = copyPile(game.Stock.Stack)
+	for i, stack := range game.Tableau.Stacks {
+		clone.Tableau.Stacks[i] = copyPile(stack)
+	}
+	for i, stack := range game.Foundations {
+		clone.Foundations[i] = copyPile(stack)
+	}
+
+	// Moves are copied too, since annotating one changes it in place. Branches
+	// share moves with the line they split from, so each move is copied once
+	// and every reference to it follows the copy.
+	values := make([]Move, 0, len(game.Moves.Prev)+len(game.Moves.Next))
+	var copies map[*Move]*Move
+	if len(game.Moves.branches) > 0 {
+		copies = make(map[*Move]*Move)
+	}
+	copyMoves := func(moves []*Move) []*Move {
+		if len(moves) == 0 {
+			return nil
+		}
+		out := make([]*Move, len(moves))
+		for i, m := range moves {
+			c, ok := copies[m]
+			if !ok {
+				if len(values) < cap(values) {
+					values = append(values, *m)
+					c = &values[len(values)-1]
+				} else {
+					c = new(Move)
+					*c = *m
+				}
+				if copies != nil {
+					copies[m] = c
+				}
+			}
+			out[i] = c
+		}
+		return out
+	}
+	clone.Moves.Prev = copyMoves(game.Moves.Prev)
+	clone.Moves.Next = copyMoves(game.Moves.Next)
+	clone.Moves.branches = nil
+	for _, b := range game.Moves.branches {
+		clone.Moves.branches = append(clone.Moves.branches, &branch{copyMoves(b.prefix), copyMoves(b.rest)})
+	}
+	clone.observers = nil
+	if game.cache != nil {
+		clone.cache = new(moveCache)
+	}
+	return &clone
+}
+
+// Build a string identifying the position, ignoring move history. Cards are
+// encoded one byte each. The stock loop only matters when it's limited.
+func (game *Game) stateKey() string {
+	b := make([]byte, 0, 96)
+	for _, stack := range game.foundations() {
+		b = append(b, byte(len(stack)))
+	}
+	for col, stack := range game.Tableau.Stacks {
+		b = append(b, '|', byte(game.Tableau.Facedown[col]))
+		for _, card := range stack {
+			b = append(b, byte(card.Suit)<<4|byte(card.Rank))
+		}
+	}
+	b = append(b, '|', byte(game.Stock.Pos))
+	if game.Stock.Limit > 0 {
+		b = append(b, byte(game.Stock.Loop))
+	}
+	for _, card := range game.Stock.Stack {
+		b = append(b, byte(card.Suit)<<4|byte(card.Rank))
+	}
+	return string(b)
+}
+
+// Hash the position, ignoring move history. Equal positions hash the same.
+func (game *Game) Hash() uint64 {
+	return hashKey(game.stateKey())
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// Check if two games are in the same position, ignoring move history.
+func (game *Game) Equal(other *Game) bool {
+	return game.stateKey() == other.stateKey()
+}
+
+// Check if two games are in the same position apart from the order of the
+// cards left to draw, which are compared as a set. With draw 1 and unlimited
+// passes every stock card comes around to the waste, so such games play out
+// alike. With draw 3 or a pass limit they may not: stock order decides which
+// cards can be reached, so one game can be winnable and the other not.
+func (game *Game) StrategicEqual(other *Game) bool {
+	return game.strategicKey() == other.strategicKey()
+}
+
+// Build a position key like stateKey, with the cards left to draw sorted.
+func (game *Game) strategicKey() string {
+	key := []byte(game.stateKey())
+	undealt := key[len(key)-(len(game.Stock.Stack)-game.Stock.Pos):]
+	sort.Slice(undealt, func(i, j int) bool { return undealt[i] < undealt[j] })
+	return string(key)
+}
+
+// Check the game's own invariants: every card of the deck appears exactly
+// once across all piles, foundations are built up in order, facedown counts
+// leave each column's top card face up, and the stock position is in range.
+// Useful for catching bugs in move code.
+func (game *Game) Validate() error {
+	r := NewRegister()
+	r.Decks = game.Decks
+	add := func(stack []*Card, format string, args ...any) error {
+		for _, card := range stack {
+			if card == nil {
+				return errorOf(ErrInvalidCard, "Missing card in %s.", fmt.Sprintf(format, args...))
+			}
+			if _, err := r.AddCard(card.Id()); err != nil {
+				return fmt.Errorf("In %s: %w", fmt.Sprintf(format, args...), err)
+			}
+		}
+		return nil
+	}
+
+	if pos := game.Stock.Pos; pos < 0 || pos > len(game.Stock.Stack) {
+		return errorOf(ErrInvalidStock, "Stock position %d is outside a stock of %d cards.", pos, len(game.Stock.Stack))
+	}
+	if err := add(game.Stock.Stack, "the stock"); err != nil {
+		return err
+	}
+	for col, stack := range game.Tableau.Stacks {
+		if col >= game.ColumnCount() && len(stack) > 0 {
+			return errorOf(ErrInvalidTableau, "Tableau %d has cards but only %d columns are in play.", col, game.ColumnCount())
+		}
+		fd := game.Tableau.Facedown[col]
+		if fd < 0 || fd > len(stack) || fd > 0 && fd == len(stack) && !game.Variant.ManualFlip {
+			return errorOf(ErrInvalidTableau, "Tableau %d has %d facedown of %d cards.", col, fd, len(stack))
+		}
+		if err := add(stack, "tableau %d", col); err != nil {
+			return err
+		}
+	}
+	for pile, stack := range game.foundations() {
+		if err := add(stack, "the %s foundation", foundationName(pile)); err != nil {
+			return err
+		}
+		if err := game.Variant.isValidFoundationStack(stack, pileSuit(pile)); err != nil {
+			return fmt.Errorf("In the %s foundation: %w", foundationName(pile), err)
+		}
+	}
+	if total := 52 * game.decks(); r.Total != total {
+		return errorOf(ErrDeckIncomplete, "Found %d cards. Game requires %d total cards. %s", r.Total, total, r.Summary())
+	}
+	return nil
+}
+
+// A Snapshot marks a point in a game's move history to return to.
+type Snapshot struct {
+	depth    int
+	last     *Move
+	next     []*Move
+	branches int
+}
+
+// Mark the current position so moves made after it can be reverted by Restore.
+// Unlike Clone, this doesn't copy any cards.
+func (game *Game) Snapshot() Snapshot {
+	s := Snapshot{depth: len(game.Moves.Prev), next: game.Moves.Next, branches: len(game.Moves.branches)}
+	if s.depth > 0 {
+		s.last = game.Moves.Prev[s.depth-1]
+	}
+	return s
+}
+
+// Revert in place every move made since the snapshot was taken. Panics if
+// moves from before the snapshot have since been undone. Observers are told
+// about each move taken back.
+func (game *Game) Restore(s Snapshot) {
+	for undone := game.restore(s); undone > 0; undone-- {
+		game.notifyUndo()
+	}
+}
+
+// Restore without telling observers. Returns how many moves were taken back.
+func (game *Game) restore(s Snapshot) int {
+	prev := game.Moves.Prev
+	if len(prev) < s.depth || (s.depth > 0 && prev[s.depth-1] != s.last) {
+		log.Panicln("Snapshot is not in the game's move history.")
+	}
+	for i := len(prev) - 1; i >= s.depth; i-- {
+		game.unapply(prev[i])
+	}
+	game.Moves.Prev = prev[:s.depth]
+	game.Moves.Next = s.next
+	if len(game.Moves.branches) > s.branches {
+		game.Moves.branches = game.Moves.branches[:s.branches]
+	}
+	return len(prev) - s.depth
+}
 
 func copyAppend[T any](slice []T, elems ...T) []T {
 	size := len(slice) + len(elems)
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// Load a save file into a new game for testing.
+func loadTestGame(t *testing.T, path string) *Game {
+	t.Helper()
+	save, err := LoadFile(path)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	game := new(Game)
+	if err := game.Import(save); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	return game
+}
+
+// Marshal a game to JSON for comparing states, including move history.
+func gameJSON(t testing.TB, game *Game) []byte {
+	t.Helper()
+	data, err := json.Marshal(game)
+	if err != nil {
+		t.Fatal("Marshal error:", err)
+	}
+	return data
+}
+
+// Parse card codes for testing.
+func mustParseCards(t *testing.T, codes ...string) []*Card {
+	t.Helper()
+	cards, err := ParseCards(codes)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	return cards
+}
+
+func TestLocate(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	game.Foundations[HEARTS] = mustParseCards(t, "hA")
+	game.Stock.Pos = 3
+
+	tests := []struct {
+		code     string
+		expected Location
+	}{
+		{"hA", Location{FOUNDATION, int(HEARTS), 0}},
+		{"d7", Location{TABLEAU, 0, 0}},
+		{"h10", Location{TABLEAU, 1, 1}},
+		{"d9", Location{TABLEAU, 6, 6}},
+		{"c2", Location{WASTE, 0, 2}},
+	}
+	for _, test := range tests {
+		card := mustParseCards(t, test.code)[0]
+		output, ok := game.Locate(card)
+		if !ok {
+			t.Errorf("Locate(%s) -> not found; expected %+v", test.code, test.expected)
+		} else if output != test.expected {
+			t.Errorf("Locate(%s) -> %+v; expected %+v", test.code, output, test.expected)
+		}
+	}
+
+	// Facedown, undrawn, and unknown cards must not be found.
+	for _, code := range []string{"c9", "hK", "sJ", "cK", "??"} {
+		card := mustParseCards(t, code)[0]
+		if output, ok := game.Locate(card); ok {
+			t.Errorf("Locate(%s) -> %+v; expected not found", code, output)
+		}
+	}
+}
+
+func TestPileTop(t *testing.T) {
+	game, err := BuildGame("stock: HQ | DK\nfoundations: *\nSK | HK")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	tests := []struct {
+		loc      Location
+		expected string // Card code, or "" for none.
+	}{
+		{Location{TABLEAU, 0, 0}, "HK"},
+		{Location{TABLEAU, 1, 0}, ""},
+		{Location{TABLEAU, 7, 0}, ""},
+		{Location{WASTE, 0, 0}, "HQ"},
+		{Location{STOCK, 0, 0}, ""},
+		{Location{FOUNDATION, int(SPADES), 0}, "SQ"},
+		{Location{FOUNDATION, int(CLUBS), 0}, "CK"},
+		{Location{FOUNDATION, 4, 0}, ""},
+	}
+	check := func(game *Game, loc Location, expected string) {
+		t.Helper()
+		output := ""
+		if card, ok := game.PileTop(loc); ok {
+			output = card.Id()
+		}
+		if output != expected {
+			t.Errorf("PileTop(%v) -> %q; expected %q", loc, output, expected)
+		}
+	}
+	for _, test := range tests {
+		check(game, test.loc, test.expected)
+	}
+
+	// Every pile of an empty game is empty.
+	for _, test := range tests {
+		check(new(Game), test.loc, "")
+	}
+
+	// A card waiting to be flipped isn't shown.
+	game.Variant.ManualFlip = true
+	if err := game.MoveTableau(0, 1, 1); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	check(game, Location{TABLEAU, 0, 0}, "")
+	check(game, Location{TABLEAU, 1, 0}, "HK")
+}
+
+func TestClone(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	expected := stateJSON(t, game)
+	clone := game.Clone()
+	if err := clone.MoveTableau(3, 1, 6); err != nil {
+		t.Fatal("MoveTableau(3, 1, 6):", err)
+	}
+	if err := clone.Draw(); err != nil {
+		t.Fatal("Draw:", err)
+	}
+	if output := stateJSON(t, game); !bytes.Equal(output, expected) {
+		t.Errorf("Moves on clone changed the original:\n\noutput:   %s\n\nexpected: %s", output, expected)
+	}
+	if len(game.Moves.Prev) != 1 {
+		t.Errorf("Original has %d moves in history; expected 1", len(game.Moves.Prev))
+	}
+
+	// Piles share one backing array in the clone, so growing one mustn't
+	// write over the next. Check against the same moves on a copy made
+	// without Clone.
+	clone = game.Clone()
+	copied, err := NewGameFromSave(game.Export())
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	for i := 0; i < 20; i++ {
+		moves := clone.LegalMoves()
+		if len(moves) == 0 {
+			break
+		}
+		m := moves[i%len(moves)]
+		if err := clone.Apply(m); err != nil {
+			t.Fatal("Apply on clone:", err)
+		}
+		if err := copied.Apply(m); err != nil {
+			t.Fatal("Apply on copy:", err)
+		}
+	}
+	output, err := CanonicalJSON(clone.Export())
+	if err != nil {
+		t.Fatal("CanonicalJSON:", err)
+	}
+	if expected, _ := CanonicalJSON(copied.Export()); !bytes.Equal(output, expected) {
+		t.Errorf("Moves on clone gave a different position:\n\noutput:   %s\n\nexpected: %s", output, expected)
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.Undo(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	expected := gameJSON(t, game)
+	s := game.Snapshot()
+	if err := game.MoveTableau(3, 1, 6); err != nil {
+		t.Fatal("MoveTableau(3, 1, 6):", err)
+	}
+	if err := game.MoveTableau(0, 1, 6); err != nil {
+		t.Fatal("MoveTableau(0, 1, 6):", err)
+	}
+	game.Restore(s)
+	if output := gameJSON(t, game); !bytes.Equal(output, expected) {
+		t.Errorf("Restore != expected:\n\noutput:   %s\n\nexpected: %s", output, expected)
+	}
+	// The redo history from before the snapshot is kept.
+	if err := game.Redo(); err != nil {
+		t.Error("Redo after Restore:", err)
+	}
+
+	// Searching with snapshots must visit the same positions as with clones.
+	expected = stateJSON(t, game)
+	if output, expected := searchSnapshot(game, 5), searchClone(game, 5); output != expected {
+		t.Errorf("Snapshot search found %d positions; clone search found %d", output, expected)
+	}
+	if output := stateJSON(t, game); !bytes.Equal(output, expected) {
+		t.Errorf("Snapshot search changed the game:\n\noutput:   %s\n\nexpected: %s", output, expected)
+	}
+}
+
+func TestZeroGame(t *testing.T) {
+	game := new(Game)
+	if moves := game.LegalMoves(); len(moves) != 0 {
+		t.Errorf("LegalMoves() -> %v; expected none", moves)
+	}
+	if _, ok := game.Locate(NewCard(ACE, SPADES)); ok {
+		t.Error("Locate(SA) -> found; expected not found")
+	}
+	var illegal *IllegalMoveError
+	if err := game.Draw(); !errors.As(err, &illegal) || illegal.Reason != STOCK_EMPTY {
+		t.Errorf("Draw() -> %v; expected STOCK_EMPTY", err)
+	}
+	if err := game.Undo(); err == nil {
+		t.Error("Undo() -> nil; expected error")
+	}
+	if _, err := NewGameFromSave(game.Export()); !errors.Is(err, ErrDeckIncomplete) {
+		t.Errorf("Import(Export()) -> %v; expected %v", err, ErrDeckIncomplete)
+	}
+
+	// Decks out of range don't reach past the foundation piles.
+	game.Decks = maxDecks + 1
+	if game.IsWon() {
+		t.Error("IsWon() with too many decks -> true; expected false")
+	}
+}
+
+func TestGameValidate(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	for i := 0; i < 20; i++ {
+		moves := game.LegalMoves()
+		if len(moves) == 0 {
+			break
+		}
+		if err := game.Apply(moves[0]); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+		if err := game.Validate(); err != nil {
+			t.Fatalf("Validate after %s -> %v; expected nil", describeMove(moves[0]), err)
+		}
+	}
+
+	tests := map[string]struct {
+		corrupt func(*Game)
+		kind    error
+	}{
+		"duplicate": {func(g *Game) {
+			g.Tableau.Stacks[0] = append(g.Tableau.Stacks[0], g.Tableau.Stacks[1][len(g.Tableau.Stacks[1])-1])
+		}, ErrDuplicateCard},
+		"missing card": {func(g *Game) { g.Stock.Stack = g.Stock.Stack[1:] }, ErrDeckIncomplete},
+		"facedown top": {func(g *Game) { g.Tableau.Facedown[6] = len(g.Tableau.Stacks[6]) }, ErrInvalidTableau},
+		"stock pos":    {func(g *Game) { g.Stock.Pos = len(g.Stock.Stack) + 1 }, ErrInvalidStock},
+	}
+	for name, test := range tests {
+		corrupted := loadTestGame(t, "game.toml")
+		test.corrupt(corrupted)
+		if err := corrupted.Validate(); !errors.Is(err, test.kind) {
+			t.Errorf("Validate with %s -> %v; expected %v", name, err, test.kind)
+		}
+	}
+}
+
+func TestCloneMoves(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if err := game.MoveTableau(3, 1, 6); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.Annotate("draw"); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	// Keep the draw as a branch off the first move.
+	if err := game.Undo(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.MoveTableau(0, 1, 6); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	expected := stateJSON(t, game)
+
+	clone := game.Clone()
+	if err := clone.Annotate("changed"); err != nil {
+		t.Fatal("Annotate on clone ->", err)
+	}
+	if err := clone.Undo(); err != nil {
+		t.Fatal("Undo on clone ->", err)
+	}
+	if note := game.History()[1].Note; note != "" {
+		t.Errorf("Annotate on clone -> original note %q; expected none", note)
+	}
+	if output := stateJSON(t, game); !bytes.Equal(output, expected) || game.UndoDepth() != 2 || game.RedoDepth() != 0 {
+		t.Error("Undo on clone changed the original game")
+	}
+
+	// The clone's branch still splits from its own copy of the first move.
+	if err := clone.SwitchBranch(0); err != nil {
+		t.Fatal("SwitchBranch on clone ->", err)
+	}
+	if clone.UndoDepth() != 1 || clone.RedoDepth() != 1 || clone.Moves.Next[0].Note != "draw" {
+		t.Errorf("SwitchBranch on clone -> %d to undo, %d to redo; expected 1, 1 with the noted draw", clone.UndoDepth(), clone.RedoDepth())
+	}
+	if len(game.Branches()) != 1 || game.Branches()[0].First.Note != "draw" {
+		t.Error("SwitchBranch on clone changed the original's branches")
+	}
+}
+
+// Count positions reachable within depth moves using clones.
+func searchClone(game *Game, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	count := 1
+	for _, m := range game.LegalMoves() {
+		child := game.Clone()
+		child.Apply(m)
+		count += searchClone(child, depth-1)
+	}
+	return count
+}
+
+// Count positions reachable within depth moves using snapshots.
+func searchSnapshot(game *Game, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	count := 1
+	for _, m := range game.LegalMoves() {
+		s := game.Snapshot()
+		game.Apply(m)
+		count += searchSnapshot(game, depth-1)
+		game.Restore(s)
+	}
+	return count
+}
+
+func BenchmarkClone(b *testing.B) {
+	game := midgame(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		game.Clone()
+	}
+}
+
+func BenchmarkCloneSearch(b *testing.B) {
+	save, _ := LoadFile("game.toml")
+	var game Game
+	game.Import(save)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		searchClone(&game, 6)
+	}
+}
+
+func BenchmarkSnapshotSearch(b *testing.B) {
+	save, _ := LoadFile("game.toml")
+	var game Game
+	game.Import(save)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		searchSnapshot(&game, 6)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"log"
+)
+
+// Rate how useful a move is to suggest. Zero means it isn't worth suggesting.
+func (game *Game) hintScore(m Move) int {
+	switch {
+	case m.isFlip():
+		return 5
+	case m.To.Category == FOUNDATION:
+		return 4
+	case m.From.Category == TABLEAU && m.To.Category == TABLEAU:
+		col := m.From.Stack
+		if game.reveals(m) {
+			return 3
+		}
+		if m.From.Index == 0 && len(game.Tableau.Stacks[m.To.Stack]) > 0 {
+			return 2
+		}
+		if m.From.Index > 0 && game.canFound(game.Tableau.Stacks[col][m.From.Index-1]) {
+			return 1
+		}
+	case m.From.Category == WASTE && m.To.Category == TABLEAU:
+		return 1
+	}
+	return 0
+}
+
+// Check if a move takes the whole face-up part of a tableau column, leaving a
+// facedown card on top.
+func (game *Game) reveals(m Move) bool {
+	return m.From.Category == TABLEAU && !m.isFlip() &&
+		m.From.Index > 0 && m.From.Index == game.Tableau.Facedown[m.From.Stack]
+}
+
+// List the legal moves that leave a facedown card on top of their column, so
+// it can be turned up. These are the moves Hint favors after flips and
+// foundation plays.
+func (game *Game) RevealingMoves() []Move {
+	var moves []Move
+	for _, m := range game.LegalMoves() {
+		if game.reveals(m) {
+			moves = append(moves, m)
+		}
+	}
+	return moves
+}
+
+// Suggest the most useful card move. Drawing from the stock is never
+// suggested. Returns false if no card move is worth making.
+func (game *Game) Hint() (Move, bool) {
+	var best Move
+	bestScore := 0
+	for _, m := range game.LegalMoves() {
+		if score := game.hintScore(m); score > bestScore {
+			best, bestScore = m, score
+		}
+	}
+	return best, bestScore > 0
+}
+
+// Find the move that draws from or recycles the stock, if there is one.
+func (game *Game) stockMove() (Move, bool) {
+	for _, m := range game.LegalMoves() {
+		if m.From.Category == STOCK || m.To.Category == STOCK {
+			return m, true
+		}
+	}
+	return Move{}, false
+}
+
+// Suggest a move like Hint, but when no card move helps, look up to draws
+// stock advances ahead for a card that does. The suggestion is then the next
+// draw toward it, so the shallowest useful card is found first. Returns false
+// if nothing within reach helps.
+func (game *Game) HintLookahead(draws int) (Move, bool) {
+	if m, ok := game.Hint(); ok {
+		return m, true
+	}
+	work := game.Clone()
+	var first Move
+	for i := 0; i < draws; i++ {
+		m, ok := work.stockMove()
+		if !ok {
+			break
+		}
+		if i == 0 {
+			first = m
+		}
+		if err := work.play(m); err != nil {
+			log.Panicln("Lookahead made an illegal move:", err)
+		}
+		if _, ok := work.Hint(); ok {
+			return first, true
+		}
+	}
+	return Move{}, false
+}
+
+// Explain the suggested move in words.
+func (game *Game) HintText() string {
+	m, ok := game.Hint()
+	if !ok {
+		return "No moves available; try drawing from the stock."
+	}
+	if m.isFlip() {
+		return fmt.Sprintf("Flip the facedown card in column %d.", m.From.Stack+1)
+	}
+
+	var from string
+	switch m.From.Category {
+	case TABLEAU:
+		from = fmt.Sprintf("from column %d", m.From.Stack+1)
+	case WASTE:
+		from = "from the waste"
+	case FOUNDATION:
+		from = fmt.Sprintf("from the %s foundation", SuitName(pileSuit(m.From.Stack)))
+	}
+
+	var to, why string
+	switch m.To.Category {
+	case FOUNDATION:
+		to = fmt.Sprintf("to the %s foundation", SuitName(pileSuit(m.To.Stack)))
+	case TABLEAU:
+		if dest := top(game.Tableau.Stacks[m.To.Stack]); dest != nil {
+			to = fmt.Sprintf("onto the %s in column %d", dest.Name(), m.To.Stack+1)
+		} else {
+			to = fmt.Sprintf("to the empty column %d", m.To.Stack+1)
+		}
+	}
+	if m.From.Category == TABLEAU {
+		switch {
+		case game.reveals(m):
+			why = " to uncover a hidden card"
+		case m.From.Index == 0:
+			why = fmt.Sprintf(" to empty column %d", m.From.Stack+1)
+		case m.To.Category == TABLEAU:
+			why = fmt.Sprintf(" to free the %s", game.Tableau.Stacks[m.From.Stack][m.From.Index-1].Name())
+		}
+	}
+
+	return fmt.Sprintf("Move the %s %s %s%s.", m.Card.Name(), from, to, why)
+}
+
+// Explain in words what keeps the game from being won, for showing after
+// Solve gives up or when no moves are left. It looks at the card each
+// foundation needs next and describes the first one that's out of reach. It's
+// only a guess: the real obstacle may be several moves deep. Only what the
+// player can see is used, so a needed card that's still hidden is described
+// by its color and rank, not where it lies.
+func (game *Game) WhyStuck() string {
+	if game.IsWon() {
+		return "The game is won."
+	}
+	// The tableau cards that some legal move can pick up.
+	movable := make(map[[2]int]bool)
+	for _, m := range game.LegalMoves() {
+		if m.From.Category == TABLEAU && !m.isFlip() {
+			movable[[2]int{m.From.Stack, m.From.Index}] = true
+		}
+	}
+	_, canDeal := game.stockMove()
+	var missing *Card // The first needed card the player hasn't seen.
+
+	for pile, stack := range game.foundations() {
+		rank, ok := game.Variant.Order.Lowest(), true
+		if card := top(stack); card != nil {
+			rank, ok = card.Rank.Next(game.Variant.Order)
+		}
+		if !ok {
+			continue
+		}
+		suit := pileSuit(pile)
+		needed := func(card *Card) bool { return card.Rank == rank && card.Suit == suit }
+
+		seen := false
+		for col, stack := range game.Tableau.Stacks {
+			for i, card := range stack {
+				if !game.IsKnown(col, i) || !needed(card) {
+					continue
+				}
+				seen = true
+				// A card on top of its column isn't blocked.
+				switch {
+				case i == len(stack)-1:
+				case i < game.Tableau.Facedown[col]:
+					blocker := "a facedown card"
+					if game.IsKnown(col, i+1) {
+						blocker = "the " + stack[i+1].Name()
+					}
+					return fmt.Sprintf("The %s is needed next but is facedown under %s in column %d.", card.Name(), blocker, col+1)
+				case !movable[[2]int{col, i + 1}]:
+					return fmt.Sprintf("The %s is needed next but is covered by the %s in column %d, which can't move.", card.Name(), stack[i+1].Name(), col+1)
+				default:
+					return fmt.Sprintf("The %s is needed next but is covered by the %s in column %d.", card.Name(), stack[i+1].Name(), col+1)
+				}
+			}
+		}
+		waste := game.Stock.Stack[:game.Stock.Pos]
+		for i, card := range waste {
+			if !needed(card) {
+				continue
+			}
+			seen = true
+			// Once the stock can't be dealt, only the waste's top card is in
+			// reach.
+			if !canDeal && i < len(waste)-1 {
+				return fmt.Sprintf("The %s is needed next but is in the waste under the %s, and the stock can't be dealt again.", card.Name(), top(waste).Name())
+			}
+		}
+		if !seen && missing == nil {
+			missing = NewCard(rank, suit)
+		}
+	}
+	if missing != nil {
+		// Point at the column hiding the most cards, without saying whether
+		// the needed card is among them.
+		most := 0
+		for col, fd := range game.Tableau.Facedown {
+			if fd > game.Tableau.Facedown[most] {
+				most = col
+			}
+		}
+		if fd := game.Tableau.Facedown[most]; fd > 0 {
+			return fmt.Sprintf("A needed %s %s hasn't turned up yet; column %d has %d facedown cards.", ColorName(missing.Color), RankName(missing.Rank), most+1, fd)
+		}
+	}
+	if len(game.LegalMoves()) == 0 {
+		return "No moves are left."
+	}
+	return "Every card the foundations need next can be reached; winning may take a longer search."
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestHint(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	m, ok := game.Hint()
+	if !ok {
+		t.Fatal("Hint -> no move; expected c8 t3>t6")
+	}
+	if output := describeMove(m); output != "c8 t3>t6" {
+		t.Errorf("Hint -> %s; expected c8 t3>t6", output)
+	}
+
+	// A playable card beats uncovering one.
+	game.Tableau.Stacks[0] = append(game.Tableau.Stacks[0], game.Stock.Stack[0])
+	game.Stock.Stack = game.Stock.Stack[1:]
+	if m, _ = game.Hint(); describeMove(m) != "sA t0>f0" {
+		t.Errorf("Hint -> %s; expected sA t0>f0", describeMove(m))
+	}
+}
+
+func TestHintText(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	expected := "Move the eight of clubs from column 4 onto the nine of diamonds in column 7 to uncover a hidden card."
+	if output := game.HintText(); output != expected {
+		t.Errorf("HintText -> %q; expected %q", output, expected)
+	}
+
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	game.Tableau.Stacks = [7][]*Card{}
+	game.Tableau.Facedown = [7]int{}
+	expected = "Move the ace of spades from the waste to the spades foundation."
+	if output := game.HintText(); output != expected {
+		t.Errorf("HintText -> %q; expected %q", output, expected)
+	}
+
+	game.Stock.Pos = 0
+	expected = "No moves available; try drawing from the stock."
+	if output := game.HintText(); output != expected {
+		t.Errorf("HintText -> %q; expected %q", output, expected)
+	}
+}
+
+func TestHintLookahead(t *testing.T) {
+	// Only twos are face up, and the ace of spades is second in the stock.
+	game := new(Game)
+	tops := mustParseCards(t, "c2", "d2", "h2", "s2")
+	stock := mustParseCards(t, "h5", "sA")
+	used := make(map[*Card]bool)
+	for _, card := range append(tops, stock...) {
+		used[card] = true
+	}
+	var rest []*Card
+	for _, card := range StandardCards() {
+		if !used[card] {
+			rest = append(rest, card)
+		}
+	}
+	game.Tableau.Stacks[0] = append(rest, tops[0])
+	game.Tableau.Facedown[0] = len(rest)
+	for col, card := range tops[1:] {
+		game.Tableau.Stacks[col+1] = []*Card{card}
+	}
+	game.Stock.Stack = stock
+
+	if m, ok := game.HintLookahead(1); ok {
+		t.Errorf("HintLookahead(1) -> %s; expected no move", describeMove(m))
+	}
+	m, ok := game.HintLookahead(2)
+	if !ok || m.From.Category != STOCK || m.Card.Id() != "H5" {
+		t.Errorf("HintLookahead(2) -> %s, %v; expected draw of h5", describeMove(m), ok)
+	}
+	if game.Stock.Pos != 0 || len(game.Moves.Prev) != 0 {
+		t.Error("HintLookahead changed the game")
+	}
+}
+
+func TestRevealingMoves(t *testing.T) {
+	// The queen uncovers the king beneath it; the jack leaves nothing behind.
+	game := endgame(t, []string{"cK", "hQ"}, []string{"sK"}, []string{"sJ"})
+	game.Tableau.Facedown[0] = 1
+	var output []string
+	for _, m := range game.RevealingMoves() {
+		output = append(output, describeMove(m))
+	}
+	if len(output) != 1 || output[0] != "hQ t0>t1" {
+		t.Errorf("RevealingMoves -> %v; expected [hQ t0>t1]", output)
+	}
+	if len(game.LegalMoves()) < 2 {
+		t.Errorf("LegalMoves -> %d moves; expected a non-revealing move too", len(game.LegalMoves()))
+	}
+}
+
+func TestWhyStuck(t *testing.T) {
+	// The ace of hearts is facedown under the two, so the player can't see it.
+	game, err := BuildGame(`
+		foundations: *
+		HK HQ HJ H10 H9 H8 H7 H6 H5 H4 H3 HA | H2`)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	expected := "A needed red ace hasn't turned up yet; column 1 has 12 facedown cards."
+	if output := game.WhyStuck(); output != expected {
+		t.Errorf("WhyStuck -> %q; expected %q", output, expected)
+	}
+
+	// Once the ace is known, it can be named.
+	game.Tableau.Known[0] |= 1 << 11
+	expected = "The ace of hearts is needed next but is facedown under the two of hearts in column 1."
+	if output := game.WhyStuck(); output != expected {
+		t.Errorf("WhyStuck with the ace known -> %q; expected %q", output, expected)
+	}
+
+	// Face up, the ace is covered by the two, which has nowhere to go.
+	game, err = BuildGame(`
+		foundations: *
+		HK HQ HJ H10 H9 H8 H7 H6 H5 H4 H3 | HA H2`)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	expected = "The ace of hearts is needed next but is covered by the two of hearts in column 1, which can't move."
+	if output := game.WhyStuck(); output != expected {
+		t.Errorf("WhyStuck -> %q; expected %q", output, expected)
+	}
+
+	// The ace is under the three in the waste after the only pass.
+	game, err = BuildGame(`
+		stock: HA H3 |
+		foundations: *
+		HK HQ HJ H10 H9 H8 H7 H6 H5 H4 | H2`)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	game.Stock.Limit = 1
+	expected = "The ace of hearts is needed next but is in the waste under the three of hearts, and the stock can't be dealt again."
+	if output := game.WhyStuck(); output != expected {
+		t.Errorf("WhyStuck -> %q; expected %q", output, expected)
+	}
+
+	// With passes left, the ace can still be reached.
+	game.Stock.Limit = 0
+	if output := game.WhyStuck(); output == expected {
+		t.Errorf("WhyStuck with a pass left -> %q; expected another reason", output)
+	}
+
+	game = endgame(t, []string{"sK"})
+	if err := game.MoveToFoundation(0); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if output := game.WhyStuck(); output != "The game is won." {
+		t.Errorf("WhyStuck on a won game -> %q; expected %q", output, "The game is won.")
+	}
+
+	// No facedown card the player hasn't seen is ever named.
+	for seed := int64(1); seed <= 20; seed++ {
+		game := NewDeal(seed)
+		game.PlayRandom(rand.New(rand.NewSource(seed)), 30)
+		output := game.WhyStuck()
+		for col, stack := range game.Tableau.Stacks {
+			for i, card := range stack {
+				if !game.IsKnown(col, i) && strings.Contains(output, card.Name()) {
+					t.Errorf("WhyStuck for seed %d -> %q; names the facedown %s", seed, output, card.Name())
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Append the game's position to a log as one line of canonical JSON.
+func (game *Game) WriteJSONL(w io.Writer) error {
+	data, err := CanonicalJSON(game.Export())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Read every position from a log written by WriteJSONL, oldest first.
+func ReadJSONL(r io.Reader) ([]*SaveData, error) {
+	var saves []*SaveData
+	dec := json.NewDecoder(r)
+	for {
+		save := new(SaveData)
+		err := dec.Decode(save)
+		if errors.Is(err, io.EOF) {
+			return saves, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Line %d of game log: %w", len(saves)+1, err)
+		}
+		saves = append(saves, save)
+	}
+}
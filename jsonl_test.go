@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestJSONL(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	var b bytes.Buffer
+	var expected [][]byte
+	steps := []func() error{
+		game.Draw,
+		func() error { return game.MoveTableau(3, 1, 6) },
+		game.Draw,
+	}
+	for i := 0; i <= len(steps); i++ {
+		if i > 0 {
+			if err := steps[i-1](); err != nil {
+				t.Fatal("Setup error:", err)
+			}
+		}
+		if err := game.WriteJSONL(&b); err != nil {
+			t.Fatal("WriteJSONL:", err)
+		}
+		expected = append(expected, stateJSON(t, game))
+	}
+	if lines := strings.Count(b.String(), "\n"); lines != len(expected) {
+		t.Errorf("WriteJSONL wrote %d lines; expected %d", lines, len(expected))
+	}
+
+	saves, err := ReadJSONL(&b)
+	if err != nil {
+		t.Fatal("ReadJSONL:", err)
+	}
+	if len(saves) != len(expected) {
+		t.Fatalf("ReadJSONL -> %d saves; expected %d", len(saves), len(expected))
+	}
+	for i, save := range saves {
+		var loaded Game
+		if err := loaded.Import(save); err != nil {
+			t.Fatalf("Import line %d: %v", i+1, err)
+		}
+		if output := stateJSON(t, &loaded); !bytes.Equal(output, expected[i]) {
+			t.Errorf("Line %d != expected:\n\noutput:   %s\n\nexpected: %s", i+1, output, expected[i])
+		}
+	}
+
+	if _, err := ReadJSONL(strings.NewReader("{}\n{not json\n")); err == nil {
+		t.Error("Expected error from malformed game log.")
+	}
+}
@@ -1,34 +1,159 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"mime"
+	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pelletier/go-toml/v2"
 )
 
+// Prefix marking a facedown tableau card whose identity is known, as in
+// "~sJ". Plain "??" stays fully unknown.
+const KNOWN_FACEDOWN = "~"
+
+// Split the known facedown prefix from a card code.
+func splitKnown(code string) (string, bool) {
+	if strings.HasPrefix(code, KNOWN_FACEDOWN) {
+		return code[len(KNOWN_FACEDOWN):], true
+	}
+	return code, false
+}
+
 type SaveData struct {
 	Stock struct {
 		Limit int
 		Loop  int
 		Pos   int
 		Stack []string
+		// Drawn cards from bottom to top, for saves that keep the waste as
+		// its own pile. Stack then holds only the undrawn cards and Pos is
+		// 0, unless Stack already starts with the waste and Pos counts it.
+		Waste []string
 	}
 	Tableau struct {
 		Stacks   [][]string
 		Facedown []int
 	}
+	// Foundation piles keyed by suit name. With two decks, the second pile
+	// of each suit is keyed with a 2, as in "spades2".
 	Foundations map[string][]string
+	// Number of decks in play; 0 means 1.
+	Decks int
+	// Seed the game was originally dealt from, if known.
+	Seed *int64
+	// Cards turned per draw; 0 means 1.
+	DrawCount int
+	// Rules the game is played by; the zero value is standard Klondike.
+	Variant Variant
+	// Details for a save manager to show. Nothing here affects the game, and
+	// binary saves leave it out.
+	Meta struct {
+		Saved   time.Time // When the save was written; zero if unknown.
+		Version string    // Version of the app that wrote the save.
+		Title   string
+	}
+	// Moves that led to the position, so Import can restore undo and redo.
+	// Nil if the save doesn't keep its history. Binary saves leave it out.
+	History *SaveHistory
 }
 
-func LoadFile(path string) (*SaveData, error) {
-	save := new(SaveData)
+// A game's move history, in move notation.
+type SaveHistory struct {
+	Start *SaveData // Position before the first move.
+	Moves []string  // Moves played from Start to the saved position, oldest first.
+	Redo  []string  // Moves taken back, next to redo first.
+}
+
+// Version of the app written into saves. Set it at build time with
+// -ldflags "-X main.Version=1.2.3".
+var Version = "dev"
+
+// Get the number of decks the save data is for.
+func (save *SaveData) decks() int {
+	if save.Decks < 1 {
+		return 1
+	}
+	return save.Decks
+}
+
+// Get the save data key of a foundation pile.
+func foundationName(pile int) string {
+	name := SuitName(pileSuit(pile))
+	if deck := pile/4 + 1; deck > 1 {
+		name += fmt.Sprint(deck)
+	}
+	return name
+}
+
+// Get the keys of every foundation pile for a number of decks, in pile order.
+func foundationNames(decks int) []string {
+	names := make([]string, 4*decks)
+	for pile := range names {
+		names[pile] = foundationName(pile)
+	}
+	return names
+}
+
+// Find the foundation pile for a save data key. Keys are matched regardless
+// of case, and the suit may be shortened to its first letter, as in "h" or
+// "s2".
+func foundationPile(key string, decks int) (int, bool) {
+	key = strings.ToLower(key)
+	for pile, name := range foundationNames(decks) {
+		if key == name || key == name[:1]+strings.TrimLeft(name, "abcdefghijklmnopqrstuvwxyz") {
+			return pile, true
+		}
+	}
+	return 0, false
+}
+
+// Get the save data's foundations in pile order, checking that every key
+// names a pile and no two keys name the same one.
+func (save *SaveData) foundationPiles() ([][]string, error) {
+	keys := make([]string, 0, len(save.Foundations))
+	for key := range save.Foundations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	piles := make([][]string, 4*save.decks())
+	named := make([]string, len(piles))
+	for _, key := range keys {
+		pile, ok := foundationPile(key, save.decks())
+		if !ok {
+			return nil, errorOf(ErrInvalidFoundation, "Unrecognized foundation name: %s", key)
+		}
+		if named[pile] != "" {
+			return nil, errorOf(ErrInvalidFoundation, "Foundation names %s and %s are the same pile.", named[pile], key)
+		}
+		named[pile] = key
+		piles[pile] = save.Foundations[key]
+	}
+	return piles, nil
+}
+
+// Deal the game's original starting position again from its seed.
+func (save *SaveData) Redeal() (*Game, error) {
+	if save.Seed == nil {
+		return nil, errors.New("Save data has no seed to redeal from.")
+	}
+	return NewDeal(*save.Seed), nil
+}
 
+func LoadFile(path string) (*SaveData, error) {
 	// Open file.
 	file, openerr := os.Open(path)
 	if openerr != nil {
@@ -42,14 +167,116 @@ func LoadFile(path string) (*SaveData, error) {
 		return nil, readerr
 	}
 
-	// Check if format is JSON or TOML.
+	// Verify the checksum sidecar, if there is one.
+	if err := verifyChecksum(path, contents); err != nil {
+		return nil, err
+	}
+
+	return decodeSave(filepath.Ext(path), contents)
+}
+
+// Load a save file from a file system, such as an embed.FS, in the format
+// given by the name's extension.
+func LoadFS(fsys fs.FS, name string) (*SaveData, error) {
+	contents, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSave(path.Ext(name), contents)
+}
+
+// Save file extensions for the media types a server may send them as.
+var saveMediaTypes = map[string]string{
+	"application/json": ".json",
+	"application/toml": ".toml",
+}
+
+// Download a save file, in the format given by the URL's extension or else
+// the response's Content-Type.
+func LoadURL(ctx context.Context, rawURL string) (*SaveData, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Loading %s failed: %s", rawURL, resp.Status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	ext := path.Ext(req.URL.Path)
+	if !saveExts[ext] {
+		if typed, ok := saveMediaTypes[mediaType]; ok {
+			ext = typed
+		} else if mediaType == "application/octet-stream" && ext == "" {
+			// Servers send files they can't type as octet-stream, so it only
+			// means a binary save when the URL gives no other hint.
+			ext = ".bin"
+		}
+	}
+	if !saveExts[ext] {
+		return nil, fmt.Errorf("Cannot tell the save format of %s from type %q.", rawURL, mediaType)
+	}
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSave(ext, contents)
+}
+
+// Write save data to a file in the format given by its extension: canonical
+// JSON, TOML, or binary. With checksum set, a sidecar file holding the save's
+// SHA-256 is written too, so LoadFile can detect later changes. Without it,
+// any sidecar left from an earlier save is removed.
+func SaveFile(save *SaveData, path string, checksum bool) error {
+	stamped := *save
+	stamped.Meta.Saved = time.Now().UTC()
+	stamped.Meta.Version = Version
+	save = &stamped
+
+	var contents []byte
+	var err error
+	switch ext := filepath.Ext(path); ext {
+	case ".json":
+		contents, err = CanonicalJSON(save)
+	case ".toml":
+		var b bytes.Buffer
+		err = save.WriteTOML(&b)
+		contents = b.Bytes()
+	case ".bin":
+		contents, err = save.MarshalBinary()
+	default:
+		return fmt.Errorf("Unsupported save file extension: %q", ext)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, contents, 0o644); err != nil {
+		return err
+	}
+	if checksum {
+		return writeChecksum(path, contents)
+	}
+	return removeChecksum(path)
+}
+
+// Decode save data in the format given by a file extension.
+func decodeSave(ext string, contents []byte) (*SaveData, error) {
+	save := new(SaveData)
+
+	// Check if format is JSON, TOML, or binary.
 	var unmarsherr error
-	ext := filepath.Ext(path)
 	switch {
 	case ext == ".json":
 		unmarsherr = json.Unmarshal(contents, save)
 	case ext == ".toml":
 		unmarsherr = toml.Unmarshal(contents, save)
+	case ext == ".bin":
+		unmarsherr = save.UnmarshalBinary(contents)
 	}
 	if unmarsherr != nil {
 		return nil, unmarsherr
@@ -58,16 +285,121 @@ func LoadFile(path string) (*SaveData, error) {
 	return save, nil
 }
 
+// The outcome of loading and importing one save file.
+type FileResult struct {
+	Path    string
+	Ok      bool
+	Err     error
+	Cards   int     // Cards listed in the save, whether or not it's valid.
+	Variant Variant // Rules the game was imported with.
+}
+
+// Extensions of save files checked by ValidateDir.
+var saveExts = map[string]bool{".json": true, ".toml": true, ".bin": true}
+
+// Load and import every save file in a directory tree, in lexical order.
+// Invalid saves are reported in the results; the error is only for failures
+// walking the directory itself.
+func ValidateDir(dir string) ([]FileResult, error) {
+	var results []FileResult
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !saveExts[filepath.Ext(path)] {
+			return nil
+		}
+		result := FileResult{Path: path}
+		save, err := LoadFile(path)
+		if err == nil {
+			result.Cards = save.cardCount()
+			var game Game
+			err = game.Import(save)
+			result.Variant = game.Variant
+		}
+		result.Ok = err == nil
+		result.Err = err
+		results = append(results, result)
+		return nil
+	})
+	return results, err
+}
+
+// Get the stock as a single pile with the waste folded in, along with how
+// many of its cards have been drawn.
+func (save *SaveData) stockPile() ([]string, int, error) {
+	stock := save.Stock
+	if len(stock.Waste) == 0 {
+		if stock.Pos < 0 || stock.Pos > len(stock.Stack) {
+			return nil, 0, errorOf(ErrInvalidStock, "Stock position %d is outside a stock of %d cards.", stock.Pos, len(stock.Stack))
+		}
+		return stock.Stack, stock.Pos, nil
+	}
+	if stock.Pos == 0 {
+		return append(append([]string(nil), stock.Waste...), stock.Stack...), len(stock.Waste), nil
+	}
+	if stock.Pos != len(stock.Waste) || stock.Pos > len(stock.Stack) {
+		return nil, 0, errorOf(ErrInvalidStock, "Stock position %d does not match a waste of %d cards.", stock.Pos, len(stock.Waste))
+	}
+	for i, code := range stock.Waste {
+		if !strings.EqualFold(code, stock.Stack[i]) {
+			return nil, 0, errorOf(ErrInvalidStock, "Waste card %d is %s but the stock has %s there.", i, code, stock.Stack[i])
+		}
+	}
+	return stock.Stack, stock.Pos, nil
+}
+
+// Count the cards listed in save data.
+func (save *SaveData) cardCount() int {
+	count := len(save.Stock.Stack) + len(save.Stock.Waste)
+	if stack, _, err := save.stockPile(); err == nil {
+		count = len(stack)
+	}
+	for _, codes := range save.Tableau.Stacks {
+		count += len(codes)
+	}
+	for _, codes := range save.Foundations {
+		count += len(codes)
+	}
+	return count
+}
+
+// Create a game from save data. The game is only returned if the save data
+// imports cleanly.
+func NewGameFromSave(save *SaveData) (*Game, error) {
+	game := new(Game)
+	if err := game.Import(save); err != nil {
+		return nil, err
+	}
+	return game, nil
+}
+
 // Load game from file
-// TODO: Add unit tests.
 func (game *Game) Import(save *SaveData) error {
+	if save.Decks < 0 || save.Decks > maxDecks {
+		return fmt.Errorf("Games can be played with 1 to %d decks, not %d.", maxDecks, save.Decks)
+	}
+	if save.Variant.Order != ACE_LOW && save.Variant.Order != ACE_HIGH {
+		return fmt.Errorf("Unknown rank order %d.", save.Variant.Order)
+	}
 	r := NewRegister()
+	r.Decks = save.Decks
+	game.Decks = save.Decks
+	game.DrawCount = save.DrawCount
+	game.Seed = save.Seed
+	game.Variant = save.Variant
+	game.invalidateMoves()
 
 	// Load stock from save data.
 	game.Stock.Limit = save.Stock.Limit
 	game.Stock.Loop = save.Stock.Loop
-	game.Stock.Pos = save.Stock.Pos
-	if stack, err := r.AddCards(save.Stock.Stack); err != nil {
+	codes, pos, err := save.stockPile()
+	if err != nil {
+		return err
+	}
+	game.Stock.Pos = pos
+	r.Pile = "the stock"
+	if stack, err := r.AddCards(codes); err != nil {
 		return err
 	} else {
 		game.Stock.Stack = stack
@@ -77,147 +409,480 @@ func (game *Game) Import(save *SaveData) error {
 	var fdTotal int // Count total facedown cards
 	tbSize := len(save.Tableau.Stacks)
 	if tbSize > 7 {
-		return fmt.Errorf("Number of stacks in tableau exceed max of 7 with %d stacks.", tbSize)
+		return errorOf(ErrTooManyStacks, "Number of stacks in tableau exceed max of 7 with %d stacks.", tbSize)
 	}
 	if len(save.Tableau.Facedown) != tbSize {
-		return errors.New("tableau.stacks and tableau.facedown lengths do not match.")
+		return errorOf(ErrInvalidTableau, "tableau.stacks and tableau.facedown lengths do not match.")
 	}
+	// Saves list only the columns in play. One with no columns at all is
+	// taken as a full tableau that happens to be empty.
+	game.Tableau.Stacks, game.Tableau.Facedown, game.Tableau.Known = [7][]*Card{}, [7]int{}, [7]uint32{}
+	game.setColumnCount(tbSize)
 	for i, codes := range save.Tableau.Stacks {
 		facedown := save.Tableau.Facedown[i]
 		fdTotal += facedown
-		if facedown >= len(codes) {
-			return fmt.Errorf("Tableau %d is invalid: Top card must not be facedown: %d cards; %d facedown.", i, len(codes), facedown)
+		if facedown > len(codes) || facedown > 0 && facedown == len(codes) && !game.Variant.ManualFlip {
+			return errorOf(ErrInvalidTableau, "Tableau %d is invalid: Top card must not be facedown: %d cards; %d facedown.", i, len(codes), facedown)
 		}
-		if stack, err := r.AddCards(codes); err != nil {
+		var known uint32
+		plain := make([]string, len(codes))
+		for j, code := range codes {
+			code, ok := splitKnown(code)
+			plain[j] = code
+			if !ok {
+				continue
+			}
+			if j >= facedown {
+				return errorOf(ErrInvalidTableau, "Tableau %d is invalid: Card %d is face up but marked as known facedown.", i, j)
+			}
+			if strings.Contains(code, "?") {
+				return errorOf(ErrInvalidTableau, "Tableau %d is invalid: Card %d is unknown but marked as known facedown.", i, j)
+			}
+			known |= 1 << j
+		}
+		r.Pile = fmt.Sprintf("tableau column %d", i)
+		if stack, err := r.AddCards(plain); err != nil {
 			return err
 		} else {
 			game.Tableau.Stacks[i] = stack
 			game.Tableau.Facedown[i] = facedown
+			game.Tableau.Known[i] = known
 		}
 	}
-	if fdTotal > 21 {
-		return fmt.Errorf("Facedown cards exceed max of 21 with %d cards.", fdTotal)
+	if fdTotal > maxFacedown {
+		return errorOf(ErrInvalidTableau, "Facedown cards exceed max of %d with %d cards.", maxFacedown, fdTotal)
 	}
 
-	// Load foundations.
-	for key, codes := range save.Foundations {
-		var suit CardSuit
-		switch {
-		case key == "spades":
-			suit = SPADES
-		case key == "clubs":
-			suit = CLUBS
-		case key == "hearts":
-			suit = HEARTS
-		case key == "diamonds":
-			suit = DIAMONDS
-		default:
-			return errors.New("Unrecognized foundation name: " + key)
+	// Load foundations. Saves may leave out empty foundations.
+	for i := range game.Foundations {
+		game.Foundations[i] = nil
+	}
+	for i := range game.foundations() {
+		game.Foundations[i] = []*Card{}
+	}
+	piles, err := save.foundationPiles()
+	if err != nil {
+		return err
+	}
+	for pile, codes := range piles {
+		if codes == nil {
+			continue
 		}
+		r.Pile = fmt.Sprintf("the %s foundation", foundationName(pile))
+		stack, err := r.AddCards(codes)
+		if err != nil {
+			return err
+		}
+		if err := game.Variant.isValidFoundationStack(stack, pileSuit(pile)); err != nil {
+			return fmt.Errorf("In %s: %w", r.Pile, err)
+		}
+		game.Foundations[pile] = stack
+	}
+	if total := 52 * save.decks(); r.Total != total {
+		return errorOf(ErrDeckIncomplete, "Found %d cards. Game requires %d total cards. %s", r.Total, total, r.Summary())
+	}
 
-		size := len(codes)
-		stack := make([]*Card, size, size)
-		for i, code := range codes {
-			card, err := r.AddCard(code)
-			if err != nil {
-				return err
+	// Moves from before the import don't belong to the new position.
+	game.Moves.Prev, game.Moves.Next, game.Moves.branches = nil, nil, nil
+	game.start.first, game.start.save = nil, nil
+	if save.History != nil {
+		return game.importHistory(save.History)
+	}
+	return nil
+}
+
+// Rebuild the move history by replaying it from its start, checking that it
+// leads to the position just imported.
+func (game *Game) importHistory(h *SaveHistory) error {
+	if h.Start == nil {
+		return errorOf(ErrInvalidHistory, "Move history has no starting position.")
+	}
+	if h.Start.History != nil {
+		return errorOf(ErrInvalidHistory, "Move history's starting position has a history of its own.")
+	}
+	replay := new(Game)
+	if err := replay.Import(h.Start); err != nil {
+		return fmt.Errorf("In the history's starting position: %w", err)
+	}
+	replay.DrawCount = game.DrawCount
+	replay.Seed = game.Seed
+
+	played := 0
+	play := func(codes []string) error {
+		for _, code := range codes {
+			played++
+			m, err := replay.ParseMove(code)
+			if err == nil {
+				err = replay.Apply(m)
 			}
-			if card.Suit == suit {
-				stack[i] = card
-			} else {
-				return fmt.Errorf("Suit mismatch in %s foundation: %s at index %d", key, code, i)
+			if err != nil {
+				return errorOf(ErrInvalidHistory, "Move %d of history: %v", played, err)
 			}
 		}
-		game.Foundations[suit] = stack
+		return nil
+	}
+	if err := play(h.Moves); err != nil {
+		return err
+	}
+	if replay.stateKey() != game.stateKey() {
+		return errorOf(ErrInvalidHistory, "Move history doesn't lead to the saved position.")
+	}
+	if err := play(h.Redo); err != nil {
+		return err
 	}
-	if r.Total != 52 {
-		return fmt.Errorf("Found %d cards. Game requires 52 total cards.", r.Total)
+	for range h.Redo {
+		replay.Undo()
 	}
 
+	// Take over the replayed piles and history, so the history's cards are
+	// the ones in play. Which facedown cards are known comes from the save.
+	known := game.Tableau.Known
+	game.Stock, game.Tableau, game.Foundations = replay.Stock, replay.Tableau, replay.Foundations
+	game.Tableau.Known = known
+	game.Moves = replay.Moves
+	game.invalidateMoves()
 	return nil
 }
 
+// Check that the save data imports into a valid game. Strict mode also checks
+// that the face-up cards of each tableau column form a run, as they must if the
+// position was reached by legal play. Leave it off for arbitrary puzzle setups.
+// The stock position isn't checked against the draw count: every card played
+// from the waste takes it back by one, so legal play can leave it anywhere.
+func (save *SaveData) Validate(strict bool) error {
+	var game Game
+	if err := game.Import(save); err != nil {
+		return err
+	}
+	if !strict {
+		return nil
+	}
+	for col, stack := range game.Tableau.Stacks {
+		fd := game.Tableau.Facedown[col]
+		if fd < len(stack) && !game.isRun(col, fd) {
+			return errorOf(ErrInvalidTableau, "Tableau %d is impossible: Face-up cards from %s up are not a valid run.", col, stack[fd].Id())
+		}
+	}
+	return nil
+}
+
+// Save the game's position. Every foundation is listed, even when empty, and
+// cards are written as their canonical codes, with known facedown cards
+// prefixed by KNOWN_FACEDOWN.
+func (game *Game) Export() *SaveData {
+	save := new(SaveData)
+	save.Stock.Limit = game.Stock.Limit
+	save.Stock.Loop = game.Stock.Loop
+	save.Stock.Pos = game.Stock.Pos
+	save.Stock.Stack = pileCodes(game.Stock.Stack, 0)
+	size := game.ColumnCount()
+	save.Tableau.Stacks = make([][]string, size)
+	save.Tableau.Facedown = make([]int, size)
+	for i, stack := range game.Tableau.Stacks[:size] {
+		save.Tableau.Stacks[i] = pileCodes(stack, 0)
+		save.Tableau.Facedown[i] = game.Tableau.Facedown[i]
+		for j := range stack {
+			if j < game.Tableau.Facedown[i] && game.Tableau.Known[i]&(1<<j) != 0 {
+				save.Tableau.Stacks[i][j] = KNOWN_FACEDOWN + stack[j].Id()
+			}
+		}
+	}
+	save.Foundations = make(map[string][]string)
+	for pile, stack := range game.foundations() {
+		save.Foundations[foundationName(pile)] = pileCodes(stack, 0)
+	}
+	save.Decks = game.Decks
+	save.Seed = game.Seed
+	save.DrawCount = game.DrawCount
+	save.Variant = game.Variant
+	return save
+}
+
+// Save the game's position like Export, along with its move history so that
+// Import can restore undo and redo.
+func (game *Game) ExportWithHistory() *SaveData {
+	save := game.Export()
+	start := game.Clone()
+	start.UndoAll()
+	h := &SaveHistory{Start: start.Export()}
+	for _, m := range game.Moves.Prev {
+		h.Moves = append(h.Moves, m.Notation())
+	}
+	for i := len(game.Moves.Next) - 1; i >= 0; i-- {
+		h.Redo = append(h.Redo, game.Moves.Next[i].Notation())
+	}
+	save.History = h
+	return save
+}
+
+// Save data laid out in a fixed order for CanonicalJSON.
+type canonicalSave struct {
+	Stock struct {
+		Limit int      `json:"limit"`
+		Loop  int      `json:"loop"`
+		Pos   int      `json:"pos"`
+		Stack []string `json:"stack"`
+	} `json:"stock"`
+	Tableau struct {
+		Stacks   [][]string `json:"stacks"`
+		Facedown []int      `json:"facedown"`
+	} `json:"tableau"`
+	Foundations canonicalFoundations `json:"foundations"`
+	Decks       int                  `json:"decks,omitempty"`
+	Seed        *int64               `json:"seed,omitempty"`
+	DrawCount   int                  `json:"drawcount,omitempty"`
+	Variant     *canonicalVariant    `json:"variant,omitempty"`
+	Meta        *canonicalMeta       `json:"meta,omitempty"`
+	History     *canonicalHistory    `json:"history,omitempty"`
+}
+
+// Game rules for CanonicalJSON, leaving out standard ones.
+type canonicalVariant struct {
+	Order      RankOrder `json:"order,omitempty"`
+	ManualFlip bool      `json:"manualflip,omitempty"`
+}
+
+// Save metadata for CanonicalJSON, leaving out unset fields.
+type canonicalMeta struct {
+	Saved   string `json:"saved,omitempty"`
+	Version string `json:"version,omitempty"`
+	Title   string `json:"title,omitempty"`
+}
+
+// A move history for CanonicalJSON, with its start in canonical form too.
+type canonicalHistory struct {
+	Start json.RawMessage `json:"start"`
+	Moves []string        `json:"moves"`
+	Redo  []string        `json:"redo,omitempty"`
+}
+
+// Foundation piles that encode as a JSON object in pile order.
+type canonicalFoundations [][]string
+
+func (f canonicalFoundations) MarshalJSON() ([]byte, error) {
+	b := []byte{'{'}
+	for pile, codes := range f {
+		if pile > 0 {
+			b = append(b, ',')
+		}
+		value, err := json.Marshal(codes)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, fmt.Sprintf("%q:", foundationName(pile))...)
+		b = append(b, value...)
+	}
+	return append(b, '}'), nil
+}
+
+// Normalize card codes to their canonical form.
+func canonicalCodes(codes []string) ([]string, error) {
+	out := make([]string, len(codes))
+	for i, code := range codes {
+		code, known := splitKnown(code)
+		card, err := ParseCard(code)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = card.Id()
+		if known {
+			out[i] = KNOWN_FACEDOWN + out[i]
+		}
+	}
+	return out, nil
+}
+
+// Encode save data as JSON that's byte-identical for identical positions:
+// fields and foundations are in a fixed order, card codes are normalized,
+// and empty piles are written as empty arrays.
+func CanonicalJSON(save *SaveData) ([]byte, error) {
+	var c canonicalSave
+	var err error
+	c.Stock.Limit = save.Stock.Limit
+	c.Stock.Loop = save.Stock.Loop
+	stock, pos, err := save.stockPile()
+	if err != nil {
+		return nil, err
+	}
+	c.Stock.Pos = pos
+	if c.Stock.Stack, err = canonicalCodes(stock); err != nil {
+		return nil, err
+	}
+	c.Tableau.Stacks = make([][]string, len(save.Tableau.Stacks))
+	for i, codes := range save.Tableau.Stacks {
+		if c.Tableau.Stacks[i], err = canonicalCodes(codes); err != nil {
+			return nil, err
+		}
+	}
+	c.Tableau.Facedown = append([]int{}, save.Tableau.Facedown...)
+	piles, err := save.foundationPiles()
+	if err != nil {
+		return nil, err
+	}
+	c.Foundations = make(canonicalFoundations, len(piles))
+	for pile, codes := range piles {
+		if c.Foundations[pile], err = canonicalCodes(codes); err != nil {
+			return nil, err
+		}
+	}
+	c.Decks = save.Decks
+	c.Seed = save.Seed
+	c.DrawCount = save.DrawCount
+	if v := save.Variant; v != (Variant{}) {
+		c.Variant = &canonicalVariant{v.Order, v.ManualFlip}
+	}
+	if meta := save.Meta; !meta.Saved.IsZero() || meta.Version != "" || meta.Title != "" {
+		c.Meta = &canonicalMeta{Version: meta.Version, Title: meta.Title}
+		if !meta.Saved.IsZero() {
+			c.Meta.Saved = meta.Saved.Format(time.RFC3339Nano)
+		}
+	}
+	if h := save.History; h != nil {
+		if h.Start == nil {
+			return nil, errorOf(ErrInvalidHistory, "Move history has no starting position.")
+		}
+		c.History = &canonicalHistory{Moves: append([]string{}, h.Moves...), Redo: h.Redo}
+		if c.History.Start, err = CanonicalJSON(h.Start); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(c)
+}
+
 type Register struct {
-	Cards map[string]struct{}
-	Suits map[CardSuit]int
-	Ranks map[CardRank]int
-	Total int
+	Decks      int            // Number of decks to allow; 0 means 1.
+	Cards      map[string]int // Copies of each known card.
+	Suits      map[CardSuit]int
+	Ranks      map[CardRank]int
+	Total      int
+	Duplicates []string
+	// Pile the cards being added come from, such as "the stock", for naming
+	// where a duplicate was found. Optional.
+	Pile  string
+	piles map[string]string // Pile each known card was first added from.
 }
 
 func NewRegister() *Register {
 	var r Register
-	r.Cards = make(map[string]struct{})
+	r.Cards = make(map[string]int)
 	r.Suits = make(map[CardSuit]int)
 	r.Ranks = make(map[CardRank]int)
 	return &r
 }
 
+// Describe the cards registered so far: the total, counts per suit and rank
+// against a full deck, and any duplicates rejected.
+func (r *Register) Summary() string {
+	var b strings.Builder
+	decks := r.decks()
+	fmt.Fprintf(&b, "Total: %d/%d.", r.Total, 52*decks)
+
+	b.WriteString(" Suits:")
+	for suit := SPADES; suit < UNKNOWN_SUIT; suit++ {
+		fmt.Fprintf(&b, " %s %d/%d,", SuitName(suit), r.Suits[suit], 13*decks)
+	}
+	if n := r.Suits[UNKNOWN_SUIT]; n > 0 {
+		fmt.Fprintf(&b, " %s %d,", SuitName(UNKNOWN_SUIT), n)
+	}
+
+	b.WriteString(" Ranks:")
+	for rank := ACE; rank < UNKNOWN_RANK; rank++ {
+		fmt.Fprintf(&b, " %s %d/%d,", RankName(rank), r.Ranks[rank], 4*decks)
+	}
+	if n := r.Ranks[UNKNOWN_RANK]; n > 0 {
+		fmt.Fprintf(&b, " %s %d,", RankName(UNKNOWN_RANK), n)
+	}
+
+	// List the missing cards when there are few enough to read.
+	if missing := r.Missing(); len(missing) > 13 {
+		fmt.Fprintf(&b, " Missing: %d cards.", len(missing))
+	} else if len(missing) > 0 {
+		fmt.Fprintf(&b, " Missing: %s.", strings.Join(missing, ", "))
+	}
+
+	b.WriteString(" Duplicates: ")
+	if len(r.Duplicates) == 0 {
+		b.WriteString("none.")
+	} else {
+		b.WriteString(strings.Join(r.Duplicates, ", ") + ".")
+	}
+	return b.String()
+}
+
+// List the cards not yet added, in suit then rank order. A card missing
+// from more than one deck is listed once per missing copy.
+func (r *Register) Missing() []string {
+	var missing []string
+	for _, card := range StandardCards() {
+		for n := r.Cards[card.Id()]; n < r.decks(); n++ {
+			missing = append(missing, card.Id())
+		}
+	}
+	return missing
+}
+
+// Get the number of decks the register allows.
+func (r *Register) decks() int {
+	if r.Decks < 1 {
+		return 1
+	}
+	return r.Decks
+}
+
 func (r *Register) AddCard(code string) (card *Card, err error) {
 	card, err = ParseCard(code)
 	if err != nil {
 		return nil, err
 	}
 
-	// Prevent duplicate cards.
+	// Prevent duplicate cards. Each deck may hold one copy.
 	id := card.Id()
-	if !strings.Contains(id, "?") {
-		if _, set := r.Cards[id]; set {
-			return nil, errors.New("Found duplicate card.")
-		} else {
-			r.Cards[id] = struct{}{}
+	known := !strings.Contains(id, "?")
+	decks := r.decks()
+	if known && r.Cards[id] >= decks {
+		r.Duplicates = append(r.Duplicates, id)
+		switch first := r.piles[id]; {
+		case r.Pile == "" || first == "":
+			return nil, errorOf(ErrDuplicateCard, "Found duplicate card %s.", id)
+		case first == r.Pile:
+			return nil, errorOf(ErrDuplicateCard, "Card %s appears twice in %s.", id, r.Pile)
+		default:
+			return nil, errorOf(ErrDuplicateCard, "Card %s appears in both %s and %s.", id, first, r.Pile)
 		}
 	}
 
-	// Prevent invalid deck.
-	var (
-		suitTotal, rankTotal int
-		ok                   bool
-	)
-	invalids := make([]string, 0, 3)
-
-	// Count all cards.
-	r.Total++
-	if r.Total > 52 {
-		r.Total--
-		invalids = append(invalids, "too many cards.")
-	}
-
-	// Count cards by suit.
-	suitTotal, ok = r.Suits[card.Suit]
-	if ok {
-		suitTotal++
-		r.Suits[card.Suit] = suitTotal
-	} else {
-		r.Suits[card.Suit] = 1
-	}
-	if suitTotal > 13 {
-		suitTotal--
-		invalids = append(invalids,
-			fmt.Sprint("too many", SuitName(card.Suit), "cards"),
-		)
+	// A full deck is a hard limit, so stop before the suit and rank counts
+	// can report anything misleading.
+	if r.Total >= 52*decks {
+		return nil, errorOf(ErrTooManyCards, "Too many cards. Game requires %d total cards.", 52*decks)
 	}
 
-	// Count cards by rank.
-	rankTotal, ok = r.Ranks[card.Rank]
-	if ok {
-		rankTotal++
-		r.Ranks[card.Rank] = rankTotal
-	} else {
-		r.Ranks[card.Rank] = 1
+	// Check suit and rank counts before tallying the card.
+	invalids := make([]string, 0, 2)
+	if r.Suits[card.Suit] >= 13*decks {
+		invalids = append(invalids, fmt.Sprintf("too many %s cards", SuitName(card.Suit)))
 	}
-	if rankTotal > 4 {
-		rankTotal--
-		invalids = append(invalids,
-			fmt.Sprint("too many", RankName(card.Rank), "cards"),
-		)
+	if r.Ranks[card.Rank] >= 4*decks {
+		invalids = append(invalids, fmt.Sprintf("too many %s cards", RankName(card.Rank)))
 	}
-
-	// check for errors.
 	if len(invalids) > 0 {
-		return nil, errors.New(strings.Join(invalids, ", ") + ".")
+		msg := strings.Join(invalids, ", ")
+		return nil, errorOf(ErrTooManyCards, "%s%s.", strings.ToUpper(msg[:1]), msg[1:])
 	}
 
-	return
+	if known {
+		r.Cards[id]++
+		if _, ok := r.piles[id]; !ok && r.Pile != "" {
+			if r.piles == nil {
+				r.piles = make(map[string]string)
+			}
+			r.piles[id] = r.Pile
+		}
+	}
+	r.Total++
+	r.Suits[card.Suit]++
+	r.Ranks[card.Rank]++
+	return card, nil
 }
 
 func (r *Register) AddCards(codes []string) (card []*Card, err error) {
@@ -2,8 +2,21 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io/fs"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
 )
 
 // TODO: Add more variations of toml and json files to load.
@@ -82,7 +95,7 @@ func TestImport(t *testing.T) {
 	}
 	exp.Tableau.Facedown = [7]int{0, 1, 2, 3, 4, 5, 6}
 	// exp foundation values.
-	exp.Foundations = [4][]*Card{
+	exp.Foundations = [8][]*Card{
 		{}, {}, {}, {},
 	}
 
@@ -153,11 +166,20 @@ func TestAddCard(t *testing.T) {
 		}
 	}
 	// Test that duplicates are rejected and no more cards than total can be added.
-	// TODO: consider adding different error types to ensure the correct type of error is handling the right error.
-	bad := []string{"sA", "c2", "hQ", "dK", "??"}
+	bad := []struct {
+		code string
+		kind error
+	}{
+		{"sA", ErrDuplicateCard},
+		{"c2", ErrDuplicateCard},
+		{"hQ", ErrDuplicateCard},
+		{"dK", ErrDuplicateCard},
+		{"??", ErrTooManyCards},
+		{"x1", ErrInvalidCard},
+	}
 	for _, b := range bad {
-		if _, err := r.AddCard(b); err == nil {
-			t.Errorf("Expected error from: (Register).AddCard(%q).", b)
+		if _, err := r.AddCard(b.code); !errors.Is(err, b.kind) {
+			t.Errorf("(Register).AddCard(%q) -> %v; expected %v", b.code, err, b.kind)
 		}
 	}
 	// Test suit constraints.
@@ -172,8 +194,8 @@ func TestAddCard(t *testing.T) {
 		}
 		// Test
 		extra := (&Card{UNKNOWN_RANK, suit, 0}).Id()
-		if _, err := r.AddCard(extra); err == nil {
-			t.Errorf("Expected error from: (Register).AddCard(%q).", extra)
+		if _, err := r.AddCard(extra); !errors.Is(err, ErrTooManyCards) {
+			t.Errorf("(Register).AddCard(%q) -> %v; expected %v", extra, err, ErrTooManyCards)
 		}
 	}
 	// Test rank constraints.
@@ -249,3 +271,896 @@ func TestAddCards(t *testing.T) {
 		}
 	}
 }
+
+func TestRedeal(t *testing.T) {
+	save, err := LoadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if _, err := save.Redeal(); err == nil {
+		t.Error("Expected error from Redeal without a seed.")
+	}
+
+	seed := int64(42)
+	save.Seed = &seed
+	var current Game
+	if err := current.Import(save); err != nil {
+		t.Fatal("Import:", err)
+	}
+	if output := stateJSON(t, &current); !bytes.Equal(output, stateJSON(t, loadTestGame(t, "game.toml"))) {
+		t.Error("Import with a seed did not load the saved position.")
+	}
+	original, err := save.Redeal()
+	if err != nil {
+		t.Fatal("Redeal:", err)
+	}
+	if output := stateJSON(t, original); !bytes.Equal(output, stateJSON(t, NewDeal(seed))) {
+		t.Error("Redeal did not deal the seed's original position.")
+	}
+}
+
+func TestRegisterSummary(t *testing.T) {
+	r := NewRegister()
+	for _, code := range testCodes[:52] {
+		if code == "H7" || code == "D7" {
+			continue
+		}
+		if _, err := r.AddCard(code); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+	}
+	r.AddCard("SA")
+
+	summary := r.Summary()
+	expected := []string{
+		"Total: 50/52.",
+		"spades 13/13,",
+		"hearts 12/13,",
+		"diamonds 12/13,",
+		"seven 2/4,",
+		"eight 4/4,",
+		"Duplicates: SA.",
+	}
+	for _, e := range expected {
+		if !strings.Contains(summary, e) {
+			t.Errorf("Summary missing %q: %s", e, summary)
+		}
+	}
+	if summary := NewRegister().Summary(); !strings.Contains(summary, "Duplicates: none.") {
+		t.Errorf("Empty register summary missing \"Duplicates: none.\": %s", summary)
+	}
+}
+
+func TestImportMissingFoundations(t *testing.T) {
+	save, err := LoadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	save.Foundations = map[string][]string{"hearts": {"hA"}}
+	save.Stock.Stack = append(save.Stock.Stack[:17], save.Stock.Stack[18:]...)
+
+	var game Game
+	if err := game.Import(save); err != nil {
+		t.Fatal("Import:", err)
+	}
+	for pile, stack := range game.foundations() {
+		if stack == nil {
+			t.Errorf("%s foundation is nil; expected empty slice.", foundationName(pile))
+		}
+	}
+	if size := len(game.Foundations[HEARTS]); size != 1 {
+		t.Errorf("hearts foundation has %d cards; expected 1", size)
+	}
+}
+
+func TestExport(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	var imported Game
+	if err := imported.Import(game.Export()); err != nil {
+		t.Fatal("Import(Export()):", err)
+	}
+	if output, expected := stateJSON(t, &imported), stateJSON(t, game); !bytes.Equal(output, expected) {
+		t.Errorf("Import(Export()) != original:\n\noutput:   %s\n\nexpected: %s", output, expected)
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	first, err := CanonicalJSON(game.Export())
+	if err != nil {
+		t.Fatal("CanonicalJSON:", err)
+	}
+	for i := 0; i < 10; i++ {
+		output, err := CanonicalJSON(game.Export())
+		if err != nil {
+			t.Fatal("CanonicalJSON:", err)
+		}
+		if !bytes.Equal(output, first) {
+			t.Fatalf("CanonicalJSON run %d differs:\n\noutput:   %s\n\nexpected: %s", i, output, first)
+		}
+	}
+
+	// Loaded saves with lower case codes and missing foundations match.
+	save, _ := LoadFile("game.json")
+	delete(save.Foundations, "clubs")
+	if output, _ := CanonicalJSON(save); !bytes.Equal(output, first) {
+		t.Errorf("CanonicalJSON(game.json) differs:\n\noutput:   %s\n\nexpected: %s", output, first)
+	}
+	if !bytes.Contains(first, []byte(`"foundations":{"spades":[],"clubs":[],"hearts":[],"diamonds":[]}`)) {
+		t.Errorf("Foundations out of suit order: %s", first)
+	}
+
+	save.Foundations["stars"] = nil
+	if _, err := CanonicalJSON(save); err == nil {
+		t.Error("Expected error from unrecognized foundation name.")
+	}
+}
+
+func TestImportKnownFacedown(t *testing.T) {
+	save, err := LoadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	// Column 6 is hK h6 dK d8 s7 h8 d9 with six facedown; mark h6 as known.
+	save.Tableau.Stacks[6][1] = "~h6"
+	var game Game
+	if err := game.Import(save); err != nil {
+		t.Fatal("Import:", err)
+	}
+	if game.IsKnown(6, 0) {
+		t.Error("IsKnown(6, 0) -> true; expected false for hidden hK")
+	}
+	if !game.IsKnown(6, 1) {
+		t.Error("IsKnown(6, 1) -> false; expected true for known h6")
+	}
+	if !game.IsKnown(6, 6) {
+		t.Error("IsKnown(6, 6) -> false; expected true for face-up d9")
+	}
+	if card := game.Tableau.Stacks[6][1]; card.Id() != "H6" {
+		t.Errorf("Known facedown card -> %s; expected H6", card.Id())
+	}
+	if output := game.Describe().Tableau[6].Cards[:2]; output[0] != "??" || output[1] != "~H6" {
+		t.Errorf("Describe() column 6 -> %v; expected [?? ~H6]", output)
+	}
+	if output := game.Export().Tableau.Stacks[6][1]; output != "~H6" {
+		t.Errorf("Export() column 6 card 1 -> %s; expected ~H6", output)
+	}
+
+	// Unknown cards stay unknown.
+	if game.IsKnown(5, 0) {
+		t.Error("IsKnown(5, 0) -> true; expected false for ??")
+	}
+
+	// The marker is only valid on known, facedown cards.
+	save.Tableau.Stacks[6][1] = "h6"
+	save.Tableau.Stacks[6][6] = "~d9"
+	if err := new(Game).Import(save); err == nil {
+		t.Error("Expected error from known marker on face-up card.")
+	}
+	save.Tableau.Stacks[6][6] = "d9"
+	save.Tableau.Stacks[5][0] = "~??"
+	if err := new(Game).Import(save); err == nil {
+		t.Error("Expected error from known marker on unknown card.")
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	// A freshly dealt game is a valid full game.
+	if err := new(Game).Import(NewDeal(1).Export()); err != nil {
+		t.Fatal("Import of a new deal ->", err)
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(save *SaveData)
+		kind    error
+		message string
+	}{
+		{"invalid card", func(save *SaveData) { save.Stock.Stack[0] = "x9" }, ErrInvalidCard, "X9"},
+		{"duplicate card", func(save *SaveData) { save.Stock.Stack[0] = "d7" }, ErrDuplicateCard, "Card D7 appears in both the stock and tableau column 0."},
+		{"duplicate in a column", func(save *SaveData) { save.Tableau.Stacks[1][0] = "h10" }, ErrDuplicateCard, "Card H10 appears twice in tableau column 1."},
+		{"duplicate on a foundation", func(save *SaveData) {
+			save.Foundations["spades"] = []string{"sA"}
+		}, ErrDuplicateCard, "Card SA appears in both the stock and the spades foundation."},
+		{"too many stacks", func(save *SaveData) {
+			save.Tableau.Stacks = append(save.Tableau.Stacks, []string{})
+			save.Tableau.Facedown = append(save.Tableau.Facedown, 0)
+		}, ErrTooManyStacks, "exceed max of 7 with 8 stacks"},
+		{"mismatched facedown lengths", func(save *SaveData) {
+			save.Tableau.Facedown = save.Tableau.Facedown[:6]
+		}, ErrInvalidTableau, "lengths do not match"},
+		{"facedown top card", func(save *SaveData) { save.Tableau.Facedown[0] = 1 }, ErrInvalidTableau, "Top card must not be facedown"},
+		{"too many facedown", func(save *SaveData) {
+			// Move a stock card into column 6, then turn every card but the tops facedown.
+			save.Tableau.Stacks[6] = append([]string{save.Stock.Stack[0]}, save.Tableau.Stacks[6]...)
+			save.Stock.Stack = save.Stock.Stack[1:]
+			for col := range save.Tableau.Stacks {
+				save.Tableau.Facedown[col] = len(save.Tableau.Stacks[col]) - 1
+			}
+		}, ErrInvalidTableau, "Facedown cards exceed max of 21"},
+		{"unknown foundation", func(save *SaveData) { save.Foundations["stars"] = nil }, ErrInvalidFoundation, "Unrecognized foundation name: stars"},
+		{"suit mismatch", func(save *SaveData) {
+			save.Stock.Stack = save.Stock.Stack[1:]
+			save.Foundations["hearts"] = []string{"sA"}
+		}, ErrInvalidFoundation, "In the hearts foundation: Suit mismatch: SA at index 0."},
+		{"deck incomplete", func(save *SaveData) { save.Stock.Stack = save.Stock.Stack[1:] }, ErrDeckIncomplete, "Found 51 cards. Game requires 52 total cards."},
+	}
+	for _, test := range tests {
+		save, err := LoadFile("game.toml")
+		if err != nil {
+			t.Fatal("Setup error:", err)
+		}
+		test.modify(save)
+		err = new(Game).Import(save)
+		if !errors.Is(err, test.kind) {
+			t.Errorf("Import with %s -> %v; expected %v", test.name, err, test.kind)
+		} else if !strings.Contains(err.Error(), test.message) {
+			t.Errorf("Import with %s -> %q; expected it to contain %q", test.name, err, test.message)
+		}
+	}
+}
+
+func TestValidateDir(t *testing.T) {
+	dir := t.TempDir()
+	valid, err := os.ReadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	files := map[string]string{
+		"a.toml":        string(valid),
+		"b.json":        `{"Stock": {"Stack": ["sA", "sA"]}}`,
+		"c.json":        `{not json`,
+		"notes.txt":     "ignored",
+		"sub/game.toml": string(valid),
+	}
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+	}
+
+	results, err := ValidateDir(dir)
+	if err != nil {
+		t.Fatal("ValidateDir:", err)
+	}
+	expected := []struct {
+		name  string
+		ok    bool
+		cards int
+		kind  error
+	}{
+		{"a.toml", true, 52, nil},
+		{"b.json", false, 2, ErrDuplicateCard},
+		{"c.json", false, 0, nil},
+		{"sub/game.toml", true, 52, nil},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("ValidateDir -> %d results; expected %d: %+v", len(results), len(expected), results)
+	}
+	for i, exp := range expected {
+		r := results[i]
+		if r.Path != filepath.Join(dir, exp.name) || r.Ok != exp.ok || r.Cards != exp.cards || (r.Err == nil) != exp.ok {
+			t.Errorf("Result %d -> %+v; expected %s ok=%v cards=%d", i, r, exp.name, exp.ok, exp.cards)
+		}
+		if exp.kind != nil && !errors.Is(r.Err, exp.kind) {
+			t.Errorf("Result %d error -> %v; expected %v", i, r.Err, exp.kind)
+		}
+	}
+
+	if _, err := ValidateDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Expected error from missing directory.")
+	}
+}
+
+func TestImportFoundationGaps(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(hearts []string) []string
+		valid  bool
+	}{
+		{"contiguous", func(h []string) []string { return h }, true},
+		{"gap", func(h []string) []string { return append(h[:1:1], h[2:]...) }, false},
+		{"no ace", func(h []string) []string { return h[1:] }, false},
+		{"out of order", func(h []string) []string { h[1], h[2] = h[2], h[1]; return h }, false},
+	}
+	for _, test := range tests {
+		save := endgame(t, []string{"sK"}).Export()
+		hearts := test.modify(save.Foundations["hearts"])
+		save.Foundations["hearts"] = hearts
+		// Cards taken off the foundation go in the stock to keep a full deck.
+		for _, code := range []string{"HA", "H2"} {
+			found := false
+			for _, h := range hearts {
+				found = found || h == code
+			}
+			if !found {
+				save.Stock.Stack = append(save.Stock.Stack, code)
+			}
+		}
+
+		err := new(Game).Import(save)
+		if test.valid && err != nil {
+			t.Errorf("Import with %s hearts foundation: %v", test.name, err)
+		}
+		if !test.valid && !errors.Is(err, ErrInvalidFoundation) {
+			t.Errorf("Import with %s hearts foundation -> %v; expected %v", test.name, err, ErrInvalidFoundation)
+		}
+	}
+}
+
+func TestSaveDataValidate(t *testing.T) {
+	save, err := LoadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	for _, strict := range []bool{false, true} {
+		if err := save.Validate(strict); err != nil {
+			t.Errorf("Validate(%v) on game.toml: %v", strict, err)
+		}
+	}
+
+	// Turning h8 face up leaves it under d9, red on red.
+	save.Tableau.Facedown[6] = 5
+	if err := save.Validate(false); err != nil {
+		t.Errorf("Validate(false) with impossible run: %v", err)
+	}
+	if err := save.Validate(true); !errors.Is(err, ErrInvalidTableau) {
+		t.Errorf("Validate(true) with impossible run -> %v; expected %v", err, ErrInvalidTableau)
+	}
+
+	// A face-up run built by legal play passes.
+	save.Tableau.Facedown[6] = 6
+	save.Tableau.Stacks[3] = []string{"c6", "h3", "s9"}
+	save.Tableau.Facedown[3] = 2
+	save.Tableau.Stacks[6] = append(save.Tableau.Stacks[6], "c8")
+	if err := save.Validate(true); err != nil {
+		t.Errorf("Validate(true) with valid run: %v", err)
+	}
+
+	// Drawing three and playing the top card leaves the stock position at 2,
+	// which strict mode accepts.
+	game, err := BuildGame("stock: | HK SK HQ\nfoundations: *")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	game.DrawCount = 3
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.PlayToFoundation(Location{WASTE, 0, 2}); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.Export().Validate(true); err != nil || game.Stock.Pos != 2 {
+		t.Errorf("Validate(true) at draw 3, stock position %d -> %v; expected nil", game.Stock.Pos, err)
+	}
+
+	// Strict mode still reports ordinary import errors.
+	save.Stock.Stack = save.Stock.Stack[1:]
+	if err := save.Validate(true); !errors.Is(err, ErrDeckIncomplete) {
+		t.Errorf("Validate(true) with missing card -> %v; expected %v", err, ErrDeckIncomplete)
+	}
+}
+
+// Build a two-deck save with the first deck on the foundations and part of
+// the second deck started on its own foundations.
+func doubleDeckSave() *SaveData {
+	save := new(SaveData)
+	save.Decks = 2
+	save.Foundations = make(map[string][]string)
+	save.Tableau.Stacks = [][]string{{"s6"}, {"hK", "d3"}, {}, {}, {}, {}, {}}
+	save.Tableau.Facedown = []int{0, 1, 0, 0, 0, 0, 0}
+	held := map[string]bool{"S6": true, "HK": true, "D3": true}
+	for _, card := range StandardCards() {
+		save.Foundations[SuitName(card.Suit)] = append(save.Foundations[SuitName(card.Suit)], card.Id())
+		switch {
+		case held[card.Id()]:
+		case card.Suit == SPADES && card.Rank < SIX, card.Suit == DIAMONDS && card.Rank < THREE:
+			key := SuitName(card.Suit) + "2"
+			save.Foundations[key] = append(save.Foundations[key], card.Id())
+		default:
+			save.Stock.Stack = append(save.Stock.Stack, card.Id())
+		}
+	}
+	return save
+}
+
+func TestImportDoubleDeck(t *testing.T) {
+	save := doubleDeckSave()
+	var game Game
+	if err := game.Import(save); err != nil {
+		t.Fatal("Import:", err)
+	}
+	if size := len(game.Foundations[4]); size != 5 {
+		t.Errorf("spades2 foundation has %d cards; expected 5", size)
+	}
+	if size := len(game.Foundations[7]); size != 2 {
+		t.Errorf("diamonds2 foundation has %d cards; expected 2", size)
+	}
+
+	// Export keeps every pile under its key.
+	exported := game.Export()
+	if exported.Decks != 2 || len(exported.Foundations) != 8 || len(exported.Foundations["spades2"]) != 5 {
+		t.Errorf("Export() -> %d decks, foundations %v; expected 2 decks with 8 piles", exported.Decks, exported.Foundations)
+	}
+	canonical, err := CanonicalJSON(exported)
+	if err != nil {
+		t.Fatal("CanonicalJSON:", err)
+	}
+	if !bytes.Contains(canonical, []byte(`"diamonds":["DA","D2","D3","D4","D5","D6","D7","D8","D9","D10","DJ","DQ","DK"],"spades2":["SA","S2","S3","S4","S5"],"clubs2":[],"hearts2":[],"diamonds2":["DA","D2"]},"decks":2`)) {
+		t.Errorf("CanonicalJSON foundations out of pile order: %s", canonical)
+	}
+
+	// Every save format round trips.
+	expected := stateJSON(t, &game)
+	var tomlData bytes.Buffer
+	if err := exported.WriteTOML(&tomlData); err != nil {
+		t.Fatal("WriteTOML:", err)
+	}
+	fromTOML := new(SaveData)
+	if err := toml.Unmarshal(tomlData.Bytes(), fromTOML); err != nil {
+		t.Fatal("TOML Unmarshal:", err)
+	}
+	binData, err := exported.MarshalBinary()
+	if err != nil {
+		t.Fatal("MarshalBinary:", err)
+	}
+	fromBinary := new(SaveData)
+	if err := fromBinary.UnmarshalBinary(binData); err != nil {
+		t.Fatal("UnmarshalBinary:", err)
+	}
+	for name, loaded := range map[string]*SaveData{"Export": exported, "TOML": fromTOML, "binary": fromBinary} {
+		var output Game
+		if err := output.Import(loaded); err != nil {
+			t.Errorf("Import from %s: %v", name, err)
+		} else if output := stateJSON(t, &output); !bytes.Equal(output, expected) {
+			t.Errorf("%s round trip != expected:\n\noutput:   %s\n\nexpected: %s", name, output, expected)
+		}
+	}
+
+	// The six of spades goes onto the second spades foundation.
+	if err := game.MoveToFoundation(0); err != nil {
+		t.Fatal("MoveToFoundation(0):", err)
+	}
+	if size := len(game.Foundations[4]); size != 6 {
+		t.Errorf("spades2 foundation has %d cards after MoveToFoundation; expected 6", size)
+	}
+
+	// A third copy of a card and second deck keys in single-deck games fail.
+	save = doubleDeckSave()
+	save.Stock.Stack = append(save.Stock.Stack, "hA")
+	if err := new(Game).Import(save); !errors.Is(err, ErrDuplicateCard) {
+		t.Errorf("Import with a third hA -> %v; expected %v", err, ErrDuplicateCard)
+	}
+	save, err = LoadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	save.Foundations["spades2"] = nil
+	if err := new(Game).Import(save); !errors.Is(err, ErrInvalidFoundation) {
+		t.Errorf("Import of spades2 in one deck -> %v; expected %v", err, ErrInvalidFoundation)
+	}
+}
+
+func TestAddCardTooMany(t *testing.T) {
+	r := NewRegister()
+	for _, card := range StandardCards() {
+		if _, err := r.AddCard(card.Id()); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+	}
+	_, err := r.AddCard("??")
+	if !errors.Is(err, ErrTooManyCards) {
+		t.Fatalf("AddCard(\"??\") for card 53 -> %v; expected %v", err, ErrTooManyCards)
+	}
+	expected := "Too many cards. Game requires 52 total cards."
+	if err.Error() != expected {
+		t.Errorf("AddCard(\"??\") for card 53 -> %q; expected %q", err, expected)
+	}
+	// The rejected card isn't tallied.
+	if r.Total != 52 || r.Suits[UNKNOWN_SUIT] != 0 || r.Ranks[UNKNOWN_RANK] != 0 {
+		t.Errorf("Register tallied the rejected card: %s", r.Summary())
+	}
+}
+
+func TestNewGameFromSave(t *testing.T) {
+	save, err := LoadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	game, err := NewGameFromSave(save)
+	if err != nil {
+		t.Fatal("NewGameFromSave ->", err)
+	}
+	if card := top(game.Tableau.Stacks[0]); card == nil || card.Id() != "D7" {
+		t.Errorf("NewGameFromSave -> column 0 top %v; expected D7", card)
+	}
+	if len(game.LegalMoves()) == 0 {
+		t.Error("NewGameFromSave -> game with no legal moves; expected some")
+	}
+
+	save.Tableau.Stacks[0] = append(save.Tableau.Stacks[0], "d7")
+	if game, err := NewGameFromSave(save); err == nil || game != nil {
+		t.Errorf("NewGameFromSave with a duplicate -> %v, %v; expected nil and an error", game, err)
+	}
+}
+
+func TestImportExplicitWaste(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	for i := 0; i < 2; i++ {
+		if err := game.Draw(); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+	}
+	expected := game.Export()
+	split := func() *SaveData {
+		save := game.Export()
+		save.Stock.Waste = save.Stock.Stack[:2]
+		save.Stock.Stack = save.Stock.Stack[2:]
+		save.Stock.Pos = 0
+		return save
+	}
+
+	imported, err := NewGameFromSave(split())
+	if err != nil {
+		t.Fatal("Import with a separate waste ->", err)
+	}
+	if !imported.Equal(game) || imported.Stock.Pos != 2 {
+		t.Errorf("Import with a separate waste -> pos %d; expected the drawn game", imported.Stock.Pos)
+	}
+	want, _ := CanonicalJSON(expected)
+	if output, err := CanonicalJSON(split()); err != nil || string(output) != string(want) {
+		t.Errorf("CanonicalJSON with a separate waste -> %s, %v; expected %s", output, err, want)
+	}
+
+	// A waste repeated at the start of the full stock is consistent.
+	full := game.Export()
+	full.Stock.Waste = full.Stock.Stack[:2]
+	if _, err := NewGameFromSave(full); err != nil {
+		t.Errorf("Import with a matching waste -> %v; expected nil", err)
+	}
+
+	inconsistent := map[string]func(*SaveData){
+		"wrong pos":  func(save *SaveData) { save.Stock.Pos = 1 },
+		"wrong card": func(save *SaveData) { save.Stock.Waste = save.Stock.Stack[1:3] },
+	}
+	for name, change := range inconsistent {
+		save := game.Export()
+		save.Stock.Waste = save.Stock.Stack[:2]
+		change(save)
+		if _, err := NewGameFromSave(save); !errors.Is(err, ErrInvalidStock) {
+			t.Errorf("Import with %s -> %v; expected ErrInvalidStock", name, err)
+		}
+	}
+
+	// Without a waste, the position must still lie within the stock.
+	for _, pos := range []int{-1, len(game.Stock.Stack) + 1, 99} {
+		save := game.Export()
+		save.Stock.Pos = pos
+		if _, err := NewGameFromSave(save); !errors.Is(err, ErrInvalidStock) {
+			t.Errorf("Import with stock pos %d of %d -> %v; expected ErrInvalidStock", pos, len(save.Stock.Stack), err)
+		}
+	}
+}
+
+func TestImportIncompleteSummary(t *testing.T) {
+	save := NewDeal(1).Export()
+	// Take the seven of hearts out wherever it is.
+	remove := func(codes []string) []string {
+		for i, code := range codes {
+			if strings.EqualFold(code, "h7") {
+				return append(codes[:i:i], codes[i+1:]...)
+			}
+		}
+		return codes
+	}
+	save.Stock.Stack = remove(save.Stock.Stack)
+	for i := range save.Tableau.Stacks {
+		save.Tableau.Stacks[i] = remove(save.Tableau.Stacks[i])
+	}
+
+	err := new(Game).Import(save)
+	if !errors.Is(err, ErrDeckIncomplete) {
+		t.Fatalf("Import -> %v; expected ErrDeckIncomplete", err)
+	}
+	for _, e := range []string{"hearts 12/13,", "seven 3/4,", "Missing: H7."} {
+		if !strings.Contains(err.Error(), e) {
+			t.Errorf("Import error missing %q: %v", e, err)
+		}
+	}
+}
+
+func TestImportFoundationKeys(t *testing.T) {
+	expected := endgame(t, []string{"sK", "hQ"}, []string{"hK", "sQ"})
+	save := expected.Export()
+	renamed := map[string]string{"spades": "Spades", "clubs": "c", "hearts": "HEARTS", "diamonds": "D"}
+	for old, key := range renamed {
+		save.Foundations[key] = save.Foundations[old]
+		delete(save.Foundations, old)
+	}
+	game, err := NewGameFromSave(save)
+	if err != nil {
+		t.Fatal("Import with renamed foundation keys ->", err)
+	}
+	if !game.Equal(expected) {
+		t.Error("Import with renamed foundation keys -> different position; expected the same game")
+	}
+	if _, err := CanonicalJSON(save); err != nil {
+		t.Error("CanonicalJSON with renamed foundation keys ->", err)
+	}
+
+	// Two keys for the same pile are ambiguous.
+	save.Foundations["s"] = []string{}
+	if _, err := NewGameFromSave(save); !errors.Is(err, ErrInvalidFoundation) {
+		t.Errorf("Import with keys Spades and s -> %v; expected ErrInvalidFoundation", err)
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	contents, err := os.ReadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	fsys := fstest.MapFS{"puzzles/game.toml": {Data: contents}}
+
+	save, err := LoadFS(fsys, "puzzles/game.toml")
+	if err != nil {
+		t.Fatal("LoadFS ->", err)
+	}
+	expected, err := LoadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	output, _ := CanonicalJSON(save)
+	want, _ := CanonicalJSON(expected)
+	if !bytes.Equal(output, want) {
+		t.Errorf("LoadFS -> %s; expected %s", output, want)
+	}
+
+	if _, err := LoadFS(fsys, "puzzles/missing.toml"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("LoadFS(missing) -> %v; expected fs.ErrNotExist", err)
+	}
+}
+
+func TestLoadURL(t *testing.T) {
+	contents, err := os.ReadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	expected, err := LoadFile("game.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	binaryContents, err := expected.MarshalBinary()
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/typed":
+			w.Header().Set("Content-Type", "application/toml; charset=utf-8")
+		case "/deal.toml":
+			w.Header().Set("Content-Type", "text/plain")
+		case "/untyped.toml":
+			w.Header().Set("Content-Type", "application/octet-stream")
+		case "/binary", "/binary.dat":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(binaryContents)
+			return
+		case "/slow":
+			<-r.Context().Done()
+			return
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(contents)
+	}))
+	defer server.Close()
+
+	want, _ := CanonicalJSON(expected)
+	for _, name := range []string{"/typed", "/deal.toml", "/untyped.toml", "/binary"} {
+		save, err := LoadURL(context.Background(), server.URL+name)
+		if err != nil {
+			t.Errorf("LoadURL(%s) -> %v", name, err)
+			continue
+		}
+		if output, _ := CanonicalJSON(save); !bytes.Equal(output, want) {
+			t.Errorf("LoadURL(%s) -> %s; expected %s", name, output, want)
+		}
+	}
+
+	if _, err := LoadURL(context.Background(), server.URL+"/missing.toml"); err == nil {
+		t.Error("LoadURL(missing) -> nil; expected error")
+	}
+	if _, err := LoadURL(context.Background(), server.URL+"/binary.dat"); err == nil {
+		t.Error("LoadURL(binary.dat as octet-stream) -> nil; expected error")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := LoadURL(ctx, server.URL+"/slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("LoadURL(slow) -> %v; expected context.DeadlineExceeded", err)
+	}
+}
+
+func TestSaveFileMeta(t *testing.T) {
+	save := NewDeal(1).Export()
+	save.Meta.Title = "my hard game"
+	before := time.Now().Add(-time.Second)
+	dir := t.TempDir()
+	for _, name := range []string{"game.json", "game.toml"} {
+		path := filepath.Join(dir, name)
+		if err := SaveFile(save, path, false); err != nil {
+			t.Fatalf("SaveFile(%s) -> %v", name, err)
+		}
+		loaded, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("LoadFile(%s) -> %v", name, err)
+		}
+		meta := loaded.Meta
+		if meta.Title != "my hard game" || meta.Version != Version || meta.Saved.Before(before) {
+			t.Errorf("LoadFile(%s) meta -> %+v; expected title, version %q, and save time", name, meta, Version)
+		}
+		if _, err := NewGameFromSave(loaded); err != nil {
+			t.Errorf("Import(%s) with meta -> %v", name, err)
+		}
+	}
+	if !save.Meta.Saved.IsZero() {
+		t.Error("SaveFile changed the caller's save time")
+	}
+
+	// Older saves have no meta block.
+	for _, path := range []string{"game.json", "game.toml"} {
+		save, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("LoadFile(%s) -> %v", path, err)
+		}
+		if save.Meta.Title != "" || !save.Meta.Saved.IsZero() {
+			t.Errorf("LoadFile(%s) meta -> %+v; expected none", path, save.Meta)
+		}
+	}
+}
+
+func TestSaveFileHistory(t *testing.T) {
+	game := NewDeal(4)
+	game.DrawCount = 3
+	played := game.PlayRandom(rand.New(rand.NewSource(4)), 30)
+	if played < 3 {
+		t.Fatal("Setup error: only", played, "moves played")
+	}
+	for i := 0; i < 2; i++ {
+		if err := game.Undo(); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+	}
+	save := game.ExportWithHistory()
+	// Compare positions through their saves, where empty piles always match.
+	position := func(game *Game) []byte {
+		data, err := CanonicalJSON(game.Export())
+		if err != nil {
+			t.Fatal("CanonicalJSON:", err)
+		}
+		return data
+	}
+
+	dir := t.TempDir()
+	for _, name := range []string{"game.json", "game.toml"} {
+		path := filepath.Join(dir, name)
+		if err := SaveFile(save, path, false); err != nil {
+			t.Fatalf("SaveFile(%s) -> %v", name, err)
+		}
+		loaded, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("LoadFile(%s) -> %v", name, err)
+		}
+		output, err := NewGameFromSave(loaded)
+		if err != nil {
+			t.Fatalf("Import(%s) with history -> %v", name, err)
+		}
+		if len(output.Moves.Prev) != played-2 || len(output.Moves.Next) != 2 {
+			t.Errorf("Import(%s) history -> %d to undo, %d to redo; expected %d, 2", name, len(output.Moves.Prev), len(output.Moves.Next), played-2)
+		}
+		if !bytes.Equal(position(output), position(game)) {
+			t.Errorf("Import(%s) with history changed the position", name)
+		}
+
+		// Undo and redo both reach the same positions as in the original game.
+		original := game.Clone()
+		output.UndoAll()
+		original.UndoAll()
+		if !bytes.Equal(position(output), position(original)) {
+			t.Errorf("Import(%s) UndoAll -> a different starting position", name)
+		}
+		output.RedoAll()
+		original.RedoAll()
+		if !bytes.Equal(position(output), position(original)) {
+			t.Errorf("Import(%s) RedoAll -> a different final position", name)
+		}
+	}
+
+	// A history that stops short of the saved position is rejected.
+	save.History.Moves = save.History.Moves[:len(save.History.Moves)-1]
+	if _, err := NewGameFromSave(save); !errors.Is(err, ErrInvalidHistory) {
+		t.Errorf("Import with a short history -> %v; expected %v", err, ErrInvalidHistory)
+	}
+	save.History.Moves = append(save.History.Moves, "SA t9>f0")
+	if _, err := NewGameFromSave(save); !errors.Is(err, ErrInvalidHistory) {
+		t.Errorf("Import with an illegal move in the history -> %v; expected %v", err, ErrInvalidHistory)
+	}
+}
+
+func TestSaveFileVariant(t *testing.T) {
+	game, err := BuildGame("stock: DK\nfoundations: *\nDQ SK | HK")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	game.Variant = Variant{Order: ACE_LOW, ManualFlip: true}
+	if err := game.MoveTableau(0, 1, 2); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	save := game.Export()
+
+	dir := t.TempDir()
+	for _, name := range []string{"game.json", "game.toml", "game.bin"} {
+		path := filepath.Join(dir, name)
+		if err := SaveFile(save, path, false); err != nil {
+			t.Fatalf("SaveFile(%s) -> %v", name, err)
+		}
+		loaded, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("LoadFile(%s) -> %v", name, err)
+		}
+		output, err := NewGameFromSave(loaded)
+		if err != nil {
+			t.Fatalf("Import(%s) awaiting a flip -> %v; expected nil", name, err)
+		}
+		if output.Variant != game.Variant {
+			t.Errorf("Import(%s) Variant -> %+v; expected %+v", name, output.Variant, game.Variant)
+		}
+		if err := output.Flip(0); err != nil {
+			t.Errorf("Import(%s) then Flip(0) -> %v; expected nil", name, err)
+		}
+	}
+
+	// An unknown rank order is rejected.
+	save.Variant.Order = ACE_HIGH + 1
+	if _, err := NewGameFromSave(save); err == nil {
+		t.Error("Import with an unknown rank order -> nil; expected an error")
+	}
+}
+
+func TestImportResetsHistory(t *testing.T) {
+	game := NewDeal(4)
+	if game.PlayRandom(rand.New(rand.NewSource(4)), 3) < 2 {
+		t.Fatal("Setup error: too few moves played")
+	}
+	game.Undo()
+	if err := game.Import(NewDeal(5).Export()); err != nil {
+		t.Fatal("Import ->", err)
+	}
+	if depth, redo := game.UndoDepth(), len(game.Moves.Next); depth != 0 || redo != 0 {
+		t.Errorf("Import of a plain save -> %d to undo, %d to redo; expected none", depth, redo)
+	}
+
+	// Importing a history keeps the game's move cache and observers.
+	played := NewDeal(4)
+	played.PlayRandom(rand.New(rand.NewSource(4)), 3)
+	observer := &recordingObserver{game: game}
+	game.Subscribe(observer)
+	game.CacheMoves(true)
+	if err := game.Import(played.ExportWithHistory()); err != nil {
+		t.Fatal("Import with history ->", err)
+	}
+	if game.UndoDepth() != played.UndoDepth() {
+		t.Errorf("Import with history -> UndoDepth %d; expected %d", game.UndoDepth(), played.UndoDepth())
+	}
+	if game.cache == nil {
+		t.Error("Import with history dropped the move cache")
+	}
+	if err := game.Undo(); err != nil || len(observer.calls) != 1 {
+		t.Errorf("Undo after Import -> %v with %d observer calls; expected 1", err, len(observer.calls))
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+// Piles are numbered for the move cache: tableau columns first, then the
+// waste, the foundation piles in order, and the stock.
+const (
+	pileWaste      = 7
+	pileFoundation = 8
+	pileStock      = pileFoundation + 4*maxDecks
+	pileCount      = pileStock + 1
+)
+
+// Get the pile number of a move's source or destination.
+func pileOf(category, stack int) int {
+	switch category {
+	case TABLEAU:
+		return stack
+	case FOUNDATION:
+		return pileFoundation + stack
+	case WASTE:
+		return pileWaste
+	}
+	return pileStock
+}
+
+// A moveCache keeps the legal moves from each pile, so only the piles
+// affected by a move need their moves listed again.
+type moveCache struct {
+	valid   bool
+	dirty   [pileCount]bool
+	sources [pileCount][]Move
+}
+
+// Keep legal moves cached between calls to LegalMoves, updating only those
+// affected by each move. The cache follows moves, undos, and imports; callers
+// that edit the game's fields directly must disable and re-enable it.
+func (game *Game) CacheMoves(enable bool) {
+	if enable {
+		game.cache = new(moveCache)
+	} else {
+		game.cache = nil
+	}
+}
+
+// Mark the piles a move changes as needing their moves listed again.
+func (game *Game) touch(m *Move) {
+	c := game.cache
+	if c == nil {
+		return
+	}
+	for _, pile := range [2]int{pileOf(m.From.Category, m.From.Stack), pileOf(m.To.Category, m.To.Stack)} {
+		if pile == pileWaste || pile == pileStock {
+			// Draws, recycles, and waste plays all change the stock stack.
+			c.dirty[pileWaste], c.dirty[pileStock] = true, true
+		} else if pile >= 0 && pile < pileCount {
+			c.dirty[pile] = true
+		}
+	}
+}
+
+// Mark every pile as needing its moves listed again.
+func (game *Game) invalidateMoves() {
+	if game.cache != nil {
+		game.cache.valid = false
+	}
+}
+
+// List the legal moves, relisting only piles whose moves may have changed.
+func (c *moveCache) legalMoves(game *Game) []Move {
+	if !c.valid {
+		for pile := range c.dirty {
+			c.dirty[pile] = true
+		}
+		c.valid = true
+	}
+	tableauChanged := false
+	for col := 0; col < pileWaste; col++ {
+		tableauChanged = tableauChanged || c.dirty[col]
+	}
+
+	size := 0
+	for src := 0; src < pileCount; src++ {
+		redo := c.dirty[src]
+		if !redo && src != pileStock {
+			// Moves onto the tableau depend on every column's top card, and
+			// moves to a foundation depend on that foundation's top card.
+			redo = tableauChanged
+			if card := game.pileTop(src); !redo && card != nil && src < pileFoundation &&
+				card.Suit >= SPADES && card.Suit < UNKNOWN_SUIT {
+				for pile := int(card.Suit); pile < len(game.foundations()); pile += 4 {
+					redo = redo || c.dirty[pileFoundation+pile]
+				}
+			}
+		}
+		if redo {
+			c.sources[src] = game.movesFrom(src, c.sources[src][:0])
+		}
+		size += len(c.sources[src])
+	}
+	c.dirty = [pileCount]bool{}
+
+	moves := make([]Move, 0, size)
+	for _, source := range c.sources {
+		moves = append(moves, source...)
+	}
+	return moves
+}
+
+// Get the top card of a pile by its number.
+func (game *Game) pileTop(pile int) *Card {
+	switch {
+	case pile < pileWaste:
+		return top(game.Tableau.Stacks[pile])
+	case pile == pileWaste:
+		return top(game.Stock.Stack[:game.Stock.Pos])
+	case pile < pileStock:
+		return top(game.Foundations[pile-pileFoundation])
+	}
+	return nil
+}
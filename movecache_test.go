@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+)
+
+// List legal moves without the cache.
+func uncachedMoves(game *Game) []Move {
+	fresh := *game
+	fresh.cache = nil
+	return fresh.LegalMoves()
+}
+
+// Check the cached legal moves match a fresh listing.
+func assertMovesFresh(t *testing.T, game *Game, step string) {
+	t.Helper()
+	output, expected := game.LegalMoves(), uncachedMoves(game)
+	if len(output) != len(expected) {
+		t.Fatalf("%s: cached LegalMoves -> %d moves; expected %d", step, len(output), len(expected))
+	}
+	for i := range output {
+		if output[i] != expected[i] {
+			t.Fatalf("%s: cached LegalMoves[%d] -> %s; expected %s", step, i, describeMove(output[i]), describeMove(expected[i]))
+		}
+	}
+}
+
+func TestMoveCache(t *testing.T) {
+	game := NewDeal(0)
+	solution, ok := game.Solve(20000)
+	if !ok {
+		t.Fatal("Setup error: no solution for seed 0.")
+	}
+	game.CacheMoves(true)
+	assertMovesFresh(t, game, "start")
+	for i, m := range solution {
+		if err := game.Apply(m); err != nil {
+			t.Fatalf("Move %d: %v", i, err)
+		}
+		assertMovesFresh(t, game, describeMove(m))
+
+		// Step back and forth through the history every few moves.
+		if i%5 == 4 {
+			game.Undo()
+			assertMovesFresh(t, game, "undo "+describeMove(m))
+			game.Undo()
+			assertMovesFresh(t, game, "undo")
+			game.Redo()
+			game.Redo()
+			assertMovesFresh(t, game, "redo "+describeMove(m))
+		}
+	}
+
+	// Clones and restores stay fresh, and imports reset the cache.
+	clone := game.Clone()
+	assertMovesFresh(t, clone, "clone")
+	clone.Undo()
+	clone.Undo()
+	s := clone.Snapshot()
+	clone.Redo()
+	assertMovesFresh(t, clone, "redo")
+	clone.Restore(s)
+	assertMovesFresh(t, clone, "restore")
+	save, _ := LoadFile("game.toml")
+	if err := game.Import(save); err != nil {
+		t.Fatal("Import:", err)
+	}
+	assertMovesFresh(t, game, "import")
+}
+
+// Replay a solution, listing legal moves after every move.
+func benchmarkLegalMoves(b *testing.B, cache bool) {
+	start := NewDeal(0)
+	solution, ok := start.Solve(20000)
+	if !ok {
+		b.Fatal("Setup error: no solution for seed 0.")
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		game := start.Clone()
+		game.CacheMoves(cache)
+		for _, m := range solution {
+			game.Apply(m)
+			game.LegalMoves()
+		}
+	}
+}
+
+func BenchmarkLegalMovesUncached(b *testing.B) {
+	benchmarkLegalMoves(b, false)
+}
+
+func BenchmarkLegalMovesCached(b *testing.B) {
+	benchmarkLegalMoves(b, true)
+}
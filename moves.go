@@ -0,0 +1,1012 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+)
+
+// Get the number of cards turned from the stock per draw.
+func (game *Game) drawCount() int {
+	if game.DrawCount < 1 {
+		return 1
+	}
+	return game.DrawCount
+}
+
+// Count the stock cards not yet drawn this pass.
+func (game *Game) StockRemaining() int {
+	return len(game.Stock.Stack) - game.Stock.Pos
+}
+
+// Count the drawn cards in the waste.
+func (game *Game) WasteCount() int {
+	return game.Stock.Pos
+}
+
+// Check if every stock card has been drawn this pass, leaving nothing to draw
+// until the waste is recycled.
+func (game *Game) StockExhausted() bool {
+	return game.StockRemaining() <= 0
+}
+
+// Check if the waste can be turned back over into the stock.
+func (game *Game) canRecycle() bool {
+	stock := &game.Stock
+	return len(stock.Stack) > 0 && game.StockExhausted() &&
+		(stock.Limit <= 0 || stock.Loop+1 < stock.Limit)
+}
+
+// Check if every card from index i to the top of a tableau stack forms a
+// valid run.
+func (game *Game) isRun(col, i int) bool {
+	stack := game.Tableau.Stacks[col]
+	for ; i < len(stack)-1; i++ {
+		if !game.Variant.CanBuild(stack[i], stack[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Get the top card of a stack, or nil if it's empty.
+func top(stack []*Card) *Card {
+	if len(stack) == 0 {
+		return nil
+	}
+	return stack[len(stack)-1]
+}
+
+// Find the first foundation pile card can be played onto.
+func (game *Game) foundationFor(card *Card) (int, bool) {
+	if card == nil || card.Suit < SPADES || card.Suit >= UNKNOWN_SUIT {
+		return 0, false
+	}
+	for pile := int(card.Suit); pile < len(game.foundations()); pile += 4 {
+		if game.Variant.CanFound(top(game.Foundations[pile]), card) {
+			return pile, true
+		}
+	}
+	return 0, false
+}
+
+// Check if card can be played onto a foundation.
+func (game *Game) canFound(card *Card) bool {
+	_, ok := game.foundationFor(card)
+	return ok
+}
+
+// Check if the top card of a tableau stack is facedown. Only possible with
+// the ManualFlip variant, before the card is flipped.
+func (game *Game) awaitingFlip(col int) bool {
+	size := len(game.Tableau.Stacks[col])
+	return size > 0 && game.Tableau.Facedown[col] >= size
+}
+
+// Flip the top card of a tableau stack if it's facedown.
+func (game *Game) flip(col int) bool {
+	if game.awaitingFlip(col) {
+		game.Tableau.Facedown[col] = len(game.Tableau.Stacks[col]) - 1
+		return true
+	}
+	return false
+}
+
+// Check if card can be built onto a tableau column. Nothing can be built on a
+// facedown card waiting to be flipped.
+func (game *Game) canBuildOn(col int, card *Card) bool {
+	return !game.awaitingFlip(col) && game.Variant.CanBuild(top(game.Tableau.Stacks[col]), card)
+}
+
+// Check if a move turns over the top card of a tableau column in place.
+func (m *Move) isFlip() bool {
+	return m.From.Category == TABLEAU && m.To.Category == TABLEAU && m.From.Stack == m.To.Stack
+}
+
+// Get the cards a move would take from its source pile.
+func (game *Game) source(m *Move) ([]*Card, error) {
+	var stack []*Card
+	i := m.From.Index
+	switch m.From.Category {
+	case TABLEAU:
+		col := m.From.Stack
+		if err := game.checkColumn(col); err != nil {
+			return nil, err
+		}
+		stack = game.Tableau.Stacks[col]
+		if i < 0 || i >= len(stack) {
+			return nil, illegalMove(EMPTY_PILE, "Tableau column %d has no card at index %d.", col, i)
+		}
+		if i < game.Tableau.Facedown[col] {
+			return nil, illegalMove(FACEDOWN_CARD, "Card %d in tableau column %d is facedown.", i, col)
+		}
+	case WASTE:
+		if game.Stock.Pos <= 0 {
+			return nil, illegalMove(EMPTY_PILE, "Waste is empty.")
+		}
+		if i != game.Stock.Pos-1 {
+			return nil, illegalMove(NOT_TOP_CARD, "Only the top card of the waste can be moved.")
+		}
+		stack = game.Stock.Stack[:game.Stock.Pos]
+	case FOUNDATION:
+		if m.From.Stack < 0 || m.From.Stack >= len(game.foundations()) {
+			return nil, illegalMove(NO_SUCH_PILE, "Foundation %d does not exist.", m.From.Stack)
+		}
+		stack = game.Foundations[m.From.Stack]
+		if len(stack) == 0 {
+			return nil, illegalMove(EMPTY_PILE, "Foundation %d is empty.", m.From.Stack)
+		}
+		if i != len(stack)-1 {
+			return nil, illegalMove(NOT_TOP_CARD, "Only the top card of a foundation can be moved.")
+		}
+	default:
+		return nil, illegalMove(NO_SUCH_PILE, "Cannot move cards from category %d.", m.From.Category)
+	}
+	cards := stack[i:]
+	if m.Card != nil && *m.Card != *cards[0] {
+		return nil, illegalMove(CARD_MISMATCH, "Expected %s but found %s.", m.Card.Id(), cards[0].Id())
+	}
+	return cards, nil
+}
+
+// Get the number of tableau columns in play.
+func (game *Game) ColumnCount() int {
+	if game.Tableau.Columns > 0 {
+		return game.Tableau.Columns
+	}
+	return len(game.Tableau.Stacks)
+}
+
+// Set the number of tableau columns in play, storing a full tableau as 0 so
+// it matches the zero value.
+func (game *Game) setColumnCount(n int) {
+	if n == len(game.Tableau.Stacks) {
+		n = 0
+	}
+	game.Tableau.Columns = n
+}
+
+// Check that a tableau column exists.
+func (game *Game) checkColumn(col int) error {
+	if col < 0 || col >= game.ColumnCount() {
+		return illegalMove(NO_SUCH_PILE, "Tableau column %d out of range; columns are 0 to %d.", col, game.ColumnCount()-1)
+	}
+	return nil
+}
+
+// Explain why card can't be built onto dest, the top of a tableau column.
+func (game *Game) buildError(dest, card *Card, col int) error {
+	if dest == nil {
+		return illegalMove(NOT_KING_ON_EMPTY, "Cannot move the %s onto empty tableau column %d; only a %s can start a column.", card.Name(), col, RankName(game.Variant.Order.Highest()))
+	}
+	if card.Color == dest.Color || card.Color == UNKNOWN_COLOR {
+		return illegalMove(COLOR_MISMATCH, "Cannot move the %s onto the %s in tableau column %d; colors must alternate.", card.Name(), dest.Name(), col)
+	}
+	return illegalMove(RANK_GAP, "Cannot move the %s onto the %s in tableau column %d; it must be one rank lower.", card.Name(), dest.Name(), col)
+}
+
+// Explain why card can't be played onto a foundation pile.
+func (game *Game) foundationError(card *Card, pile int) error {
+	if pile < 0 || pile >= len(game.foundations()) || card.Suit != pileSuit(pile) {
+		return illegalMove(WRONG_SUIT, "Cannot move %s to foundation %d.", card.Id(), pile)
+	}
+	dest := top(game.Foundations[pile])
+	if dest == nil {
+		return illegalMove(NOT_ACE_ON_EMPTY, "Cannot move the %s to the empty %s foundation; it must start with a %s.", card.Name(), SuitName(card.Suit), RankName(game.Variant.Order.Lowest()))
+	}
+	return illegalMove(RANK_GAP, "Cannot move the %s onto the %s on its foundation; it must be one rank higher.", card.Name(), dest.Name())
+}
+
+// Check a move's legality and perform it without recording it.
+func (game *Game) apply(m *Move) error {
+	game.touch(m)
+	m.flipped = false
+
+	// Turn over stock cards.
+	if m.From.Category == STOCK && m.To.Category == WASTE {
+		stock := &game.Stock
+		if game.StockExhausted() {
+			return illegalMove(STOCK_EMPTY, "Stock is empty.")
+		}
+		m.Card = stock.Stack[stock.Pos]
+		m.From.Index = stock.Pos
+		stock.Pos += game.drawCount()
+		if stock.Pos > len(stock.Stack) {
+			stock.Pos = len(stock.Stack)
+		}
+		return nil
+	}
+
+	// Turn the waste back over into the stock.
+	if m.From.Category == WASTE && m.To.Category == STOCK {
+		if !game.canRecycle() {
+			stock := &game.Stock
+			switch {
+			case !game.StockExhausted():
+				return illegalMove(CANNOT_RECYCLE, "Stock cannot be recycled until every card is drawn.")
+			case len(stock.Stack) > 0:
+				return illegalMove(CANNOT_RECYCLE, "Stock cannot be recycled: all %d passes are used.", stock.Limit)
+			}
+			return illegalMove(CANNOT_RECYCLE, "Stock cannot be recycled.")
+		}
+		m.Card = nil
+		game.Stock.Pos = 0
+		game.Stock.Loop++
+		return nil
+	}
+
+	// Turn over a facedown card left on top of a tableau column.
+	if m.isFlip() {
+		col := m.From.Stack
+		if err := game.checkColumn(col); err != nil {
+			return err
+		}
+		if !game.awaitingFlip(col) {
+			return illegalMove(SAME_COLUMN, "Cannot move cards onto their own column.")
+		}
+		card := top(game.Tableau.Stacks[col])
+		if m.Card != nil && *m.Card != *card {
+			return illegalMove(CARD_MISMATCH, "Expected %s but found %s.", m.Card.Id(), card.Id())
+		}
+		m.Card = card
+		m.From.Index = len(game.Tableau.Stacks[col]) - 1
+		m.flipped = game.flip(col)
+		return nil
+	}
+
+	cards, err := game.source(m)
+	if err != nil {
+		return err
+	}
+	card := cards[0]
+
+	switch m.To.Category {
+	case FOUNDATION:
+		if len(cards) > 1 {
+			return illegalMove(TOO_MANY_TO_FOUNDATION, "Only one card can be moved to a foundation at a time.")
+		}
+		pile := m.To.Stack
+		if pile < 0 || pile >= len(game.foundations()) || card.Suit != pileSuit(pile) ||
+			!game.Variant.CanFound(top(game.Foundations[pile]), card) {
+			return game.foundationError(card, pile)
+		}
+	case TABLEAU:
+		col := m.To.Stack
+		if err := game.checkColumn(col); err != nil {
+			return err
+		}
+		if game.awaitingFlip(col) {
+			return illegalMove(FACEDOWN_CARD, "Cannot move the %s onto tableau column %d; its top card is facedown.", card.Name(), col)
+		}
+		if dest := top(game.Tableau.Stacks[col]); !game.Variant.CanBuild(dest, card) {
+			return game.buildError(dest, card, col)
+		}
+		if m.From.Category == TABLEAU && !game.isRun(m.From.Stack, m.From.Index) {
+			return illegalMove(NOT_A_RUN, "Cards above %s in tableau column %d are not a valid run.", card.Id(), m.From.Stack)
+		}
+	default:
+		return illegalMove(NO_SUCH_PILE, "Cannot move cards to category %d.", m.To.Category)
+	}
+	m.Card = card
+
+	// Add cards to destination.
+	if m.To.Category == FOUNDATION {
+		game.Foundations[m.To.Stack] = append(game.Foundations[m.To.Stack], card)
+	} else {
+		game.Tableau.Stacks[m.To.Stack] = append(game.Tableau.Stacks[m.To.Stack], cards...)
+	}
+
+	// Remove cards from source.
+	switch m.From.Category {
+	case TABLEAU:
+		col := m.From.Stack
+		game.Tableau.Stacks[col] = game.Tableau.Stacks[col][:m.From.Index]
+		if !game.Variant.ManualFlip {
+			m.flipped = game.flip(col)
+		}
+	case WASTE:
+		stock := &game.Stock
+		stock.Stack = append(stock.Stack[:stock.Pos-1], stock.Stack[stock.Pos:]...)
+		stock.Pos--
+	case FOUNDATION:
+		game.Foundations[m.From.Stack] = game.Foundations[m.From.Stack][:m.From.Index]
+	}
+	return nil
+}
+
+// Revert a move performed by apply.
+func (game *Game) unapply(m *Move) {
+	game.touch(m)
+	stock := &game.Stock
+	switch {
+	case m.From.Category == STOCK && m.To.Category == WASTE:
+		stock.Pos = m.From.Index
+		return
+	case m.From.Category == WASTE && m.To.Category == STOCK:
+		stock.Pos = len(stock.Stack)
+		stock.Loop--
+		return
+	case m.isFlip():
+		game.Tableau.Facedown[m.From.Stack]++
+		return
+	}
+
+	// Take cards back off the destination.
+	var cards []*Card
+	if m.To.Category == FOUNDATION {
+		stack := game.Foundations[m.To.Stack]
+		cards = stack[len(stack)-1:]
+		game.Foundations[m.To.Stack] = stack[:len(stack)-1]
+	} else {
+		stack := game.Tableau.Stacks[m.To.Stack]
+		i := len(stack) - 1
+		for stack[i] != m.Card {
+			i--
+		}
+		cards = stack[i:]
+		game.Tableau.Stacks[m.To.Stack] = stack[:i]
+	}
+
+	// Return cards to source.
+	switch m.From.Category {
+	case TABLEAU:
+		col := m.From.Stack
+		if m.flipped {
+			game.Tableau.Facedown[col]++
+		}
+		game.Tableau.Stacks[col] = append(game.Tableau.Stacks[col], cards...)
+	case WASTE:
+		stock.Stack = append(stock.Stack, nil)
+		copy(stock.Stack[stock.Pos+1:], stock.Stack[stock.Pos:])
+		stock.Stack[stock.Pos] = cards[0]
+		stock.Pos++
+	case FOUNDATION:
+		game.Foundations[m.From.Stack] = append(game.Foundations[m.From.Stack], cards[0])
+	}
+}
+
+// Perform a move if it's legal and record it in the move history. Moves that
+// were left to redo are kept as a branch rather than discarded.
+func (game *Game) Apply(m Move) error {
+	if err := game.play(m); err != nil {
+		return err
+	}
+	game.notifyMove(m)
+	return nil
+}
+
+// Apply without telling observers, for moves that may be taken back before
+// anyone sees them.
+func (game *Game) play(m Move) error {
+	if err := game.apply(&m); err != nil {
+		return err
+	}
+	if len(game.Moves.Next) > 0 {
+		game.keepBranch()
+	}
+	game.Moves.Prev = append(game.Moves.Prev, &m)
+	game.Moves.Next = nil
+	return nil
+}
+
+// Apply moves in order, stopping at the first illegal one. Returns how many
+// were applied; those stay applied even if a later move fails.
+func (game *Game) MoveMany(moves []Move) (applied int, err error) {
+	for i, m := range moves {
+		if err := game.Apply(m); err != nil {
+			return i, fmt.Errorf("Move %d of %d: %w", i, len(moves), err)
+		}
+	}
+	return len(moves), nil
+}
+
+// Apply moves in order as a single step. If any move is illegal, the game is
+// restored to where it was before the first move. Observers are only told
+// once every move has been made.
+func (game *Game) MoveManyAtomic(moves []Move) error {
+	s := game.Snapshot()
+	for i, m := range moves {
+		if err := game.play(m); err != nil {
+			game.restore(s)
+			return fmt.Errorf("Move %d of %d: %w", i, len(moves), err)
+		}
+	}
+	game.notifyMoves(moves)
+	return nil
+}
+
+// Get the game as it would be after a move, leaving the game itself untouched.
+func (game *Game) Preview(m Move) (*Game, error) {
+	clone := game.Clone()
+	if err := clone.Apply(m); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// Move the top count cards of one tableau column onto another.
+func (game *Game) MoveTableau(fromCol, count, toCol int) error {
+	if err := game.checkColumn(fromCol); err != nil {
+		return err
+	}
+	if err := game.checkColumn(toCol); err != nil {
+		return err
+	}
+	if fromCol == toCol {
+		return illegalMove(SAME_COLUMN, "Source and destination are the same column, %d.", fromCol)
+	}
+	if count < 1 {
+		return illegalMove(NO_CARDS, "Cannot move %d cards; at least one is needed.", count)
+	}
+	faceup := len(game.Tableau.Stacks[fromCol]) - game.Tableau.Facedown[fromCol]
+	if count > faceup {
+		return illegalMove(FACEDOWN_CARD, "Only %d face-up cards available in tableau column %d, requested %d.", faceup, fromCol, count)
+	}
+	var m Move
+	m.From.Category = TABLEAU
+	m.From.Stack = fromCol
+	m.From.Index = len(game.Tableau.Stacks[fromCol]) - count
+	m.To.Category = TABLEAU
+	m.To.Stack = toCol
+	return game.Apply(m)
+}
+
+// Move the face-up card of the given rank in one tableau column, along with
+// every card above it, onto another column.
+func (game *Game) MoveRunAuto(fromCol int, rank CardRank, toCol int) error {
+	if err := game.checkColumn(fromCol); err != nil {
+		return err
+	}
+	stack := game.Tableau.Stacks[fromCol]
+	for i := len(stack) - 1; i >= game.Tableau.Facedown[fromCol]; i-- {
+		if stack[i].Rank == rank {
+			if !game.isRun(fromCol, i) {
+				return illegalMove(NOT_A_RUN, "Cards from the %s up in tableau column %d are not a valid run.", stack[i].Name(), fromCol)
+			}
+			return game.MoveTableau(fromCol, len(stack)-i, toCol)
+		}
+	}
+	return illegalMove(CARD_MISMATCH, "No face-up %s in tableau column %d.", RankName(rank), fromCol)
+}
+
+// Check if a card dragged from a face-up part of one tableau column, along with
+// every card above it, can be dropped onto another column.
+func (game *Game) CanDrop(fromCol int, card *Card, toCol int) bool {
+	cols := game.ColumnCount()
+	if card == nil || fromCol < 0 || fromCol >= cols || toCol < 0 || toCol >= cols || fromCol == toCol {
+		return false
+	}
+	stack := game.Tableau.Stacks[fromCol]
+	for i := len(stack) - 1; i >= game.Tableau.Facedown[fromCol]; i-- {
+		if *stack[i] == *card {
+			return game.isRun(fromCol, i) && game.canBuildOn(toCol, card)
+		}
+	}
+	return false
+}
+
+// Check if a move could be taken back in real play without giving anything
+// away. Moves that turn a facedown card face up, or that recycle the stock
+// into a new pass, can't be.
+func (game *Game) IsReversible(m Move) bool {
+	switch {
+	case m.From.Category == WASTE && m.To.Category == STOCK, m.isFlip():
+		return false
+	case m.From.Category == TABLEAU && !game.Variant.ManualFlip:
+		col := m.From.Stack
+		return col < 0 || col >= game.ColumnCount() || m.From.Index <= 0 || m.From.Index != game.Tableau.Facedown[col]
+	}
+	return true
+}
+
+// Move the top card of a tableau column to its foundation.
+func (game *Game) MoveToFoundation(fromCol int) error {
+	if err := game.checkColumn(fromCol); err != nil {
+		return err
+	}
+	card := top(game.Tableau.Stacks[fromCol])
+	if card == nil {
+		return illegalMove(EMPTY_PILE, "Tableau column %d is empty.", fromCol)
+	}
+	return game.PlayToFoundation(Location{TABLEAU, fromCol, len(game.Tableau.Stacks[fromCol]) - 1})
+}
+
+// Move the card at a tableau or waste location to whichever foundation pile
+// will take it.
+func (game *Game) PlayToFoundation(loc Location) error {
+	var m Move
+	m.From = loc
+	cards, err := game.source(&m)
+	if err != nil {
+		return err
+	}
+	m.To.Category = FOUNDATION
+	m.To.Stack = int(cards[0].Suit)
+	if pile, ok := game.foundationFor(cards[0]); ok {
+		m.To.Stack = pile
+	}
+	return game.Apply(m)
+}
+
+// Turn over the facedown card on top of a tableau column. Cards are only left
+// facedown for this with the ManualFlip variant; otherwise they're turned over
+// as soon as they're uncovered.
+func (game *Game) Flip(col int) error {
+	if err := game.checkColumn(col); err != nil {
+		return err
+	}
+	if !game.awaitingFlip(col) {
+		return illegalMove(NOTHING_TO_FLIP, "Tableau column %d has no facedown card on top to flip.", col)
+	}
+	var m Move
+	m.From.Category, m.From.Stack = TABLEAU, col
+	m.To.Category, m.To.Stack = TABLEAU, col
+	return game.Apply(m)
+}
+
+// Turn over the next cards of the stock onto the waste.
+func (game *Game) Draw() error {
+	var m Move
+	m.From.Category = STOCK
+	m.To.Category = WASTE
+	return game.Apply(m)
+}
+
+// Turn the waste back over into the stock once every card has been drawn.
+func (game *Game) RecycleStock() error {
+	var m Move
+	m.From.Category = WASTE
+	m.To.Category = STOCK
+	return game.Apply(m)
+}
+
+// Take back the last move.
+func (game *Game) Undo() error {
+	size := len(game.Moves.Prev)
+	if size == 0 {
+		return errors.New("No moves to undo.")
+	}
+	m := game.Moves.Prev[size-1]
+	game.unapply(m)
+	game.Moves.Prev = game.Moves.Prev[:size-1]
+	game.Moves.Next = append(game.Moves.Next, m)
+	game.notifyUndo()
+	return nil
+}
+
+// Perform the last move taken back by Undo.
+func (game *Game) Redo() error {
+	size := len(game.Moves.Next)
+	if size == 0 {
+		return errors.New("No moves to redo.")
+	}
+	m := game.Moves.Next[size-1]
+	if err := game.apply(m); err != nil {
+		return err
+	}
+	game.Moves.Next = game.Moves.Next[:size-1]
+	game.Moves.Prev = append(game.Moves.Prev, m)
+	game.notifyMove(*m)
+	return nil
+}
+
+// Check if there's a move to undo.
+func (game *Game) CanUndo() bool {
+	return len(game.Moves.Prev) > 0
+}
+
+// Check if there's a move to redo.
+func (game *Game) CanRedo() bool {
+	return len(game.Moves.Next) > 0
+}
+
+// Count the moves that can be undone.
+func (game *Game) UndoDepth() int {
+	return len(game.Moves.Prev)
+}
+
+// Count the moves that can be redone.
+func (game *Game) RedoDepth() int {
+	return len(game.Moves.Next)
+}
+
+// Get the most recently played move. Returns false if there's none.
+func (game *Game) LastMove() (Move, bool) {
+	size := len(game.Moves.Prev)
+	if size == 0 {
+		return Move{}, false
+	}
+	return *game.Moves.Prev[size-1], true
+}
+
+// Attach a note to the most recently played move. The note stays with the
+// move through undo and redo.
+func (game *Game) Annotate(note string) error {
+	size := len(game.Moves.Prev)
+	if size == 0 {
+		return errors.New("No moves to annotate.")
+	}
+	game.Moves.Prev[size-1].Note = note
+	return nil
+}
+
+// List the moves played so far, oldest first.
+func (game *Game) History() []Move {
+	history := make([]Move, len(game.Moves.Prev))
+	for i, m := range game.Moves.Prev {
+		history[i] = *m
+	}
+	return history
+}
+
+// List the moves played from index on, oldest first. A client can keep the
+// history length as a cursor and poll for just the moves it hasn't seen. An
+// index past the end of the history, as after an undo, gives no moves.
+func (game *Game) MovesSince(index int) []Move {
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(game.Moves.Prev) {
+		return nil
+	}
+	moves := make([]Move, 0, len(game.Moves.Prev)-index)
+	for _, m := range game.Moves.Prev[index:] {
+		moves = append(moves, *m)
+	}
+	return moves
+}
+
+// Take back every move, returning to the start of the history.
+func (game *Game) UndoAll() {
+	for len(game.Moves.Prev) > 0 {
+		game.Undo()
+	}
+}
+
+// Redo every move taken back, returning to the end of the history.
+func (game *Game) RedoAll() error {
+	for len(game.Moves.Next) > 0 {
+		if err := game.Redo(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Undo or redo moves until exactly index moves of the history are played.
+func (game *Game) Seek(index int) error {
+	if index < 0 || index > len(game.Moves.Prev)+len(game.Moves.Next) {
+		return fmt.Errorf("Move %d is outside the history of %d moves.", index, len(game.Moves.Prev)+len(game.Moves.Next))
+	}
+	for len(game.Moves.Prev) > index {
+		game.Undo()
+	}
+	for len(game.Moves.Prev) < index {
+		if err := game.Redo(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List every legal move in the current position.
+func (game *Game) LegalMoves() []Move {
+	if game.cache != nil {
+		return game.cache.legalMoves(game)
+	}
+	var moves []Move
+	for src := 0; src < pileCount; src++ {
+		moves = game.movesFrom(src, moves)
+	}
+	return moves
+}
+
+// Append the legal moves from a pile to moves. See pileOf for pile numbers.
+func (game *Game) movesFrom(src int, moves []Move) []Move {
+	var m Move
+	stacks := &game.Tableau.Stacks
+
+	switch {
+	// Tableau to foundation and tableau.
+	case src < pileWaste:
+		col := src
+		stack := stacks[col]
+		card := top(stack)
+		if card == nil {
+			break
+		}
+		if game.awaitingFlip(col) {
+			m = Move{Card: card}
+			m.From.Category, m.From.Stack, m.From.Index = TABLEAU, col, len(stack)-1
+			m.To.Category, m.To.Stack = TABLEAU, col
+			moves = append(moves, m)
+			break
+		}
+		if pile, ok := game.foundationFor(card); ok {
+			m = Move{Card: card}
+			m.From.Category, m.From.Stack, m.From.Index = TABLEAU, col, len(stack)-1
+			m.To.Category, m.To.Stack = FOUNDATION, pile
+			moves = append(moves, m)
+		}
+		for i := len(stack) - 1; i >= game.Tableau.Facedown[col]; i-- {
+			if i < len(stack)-1 && !game.Variant.CanBuild(stack[i], stack[i+1]) {
+				break
+			}
+			for to := range stacks {
+				if to != col && game.canBuildOn(to, stack[i]) {
+					m = Move{Card: stack[i]}
+					m.From.Category, m.From.Stack, m.From.Index = TABLEAU, col, i
+					m.To.Category, m.To.Stack = TABLEAU, to
+					moves = append(moves, m)
+				}
+			}
+		}
+
+	// Waste to foundation and tableau.
+	case src == pileWaste:
+		pos := game.Stock.Pos
+		if pos <= 0 {
+			break
+		}
+		card := game.Stock.Stack[pos-1]
+		if pile, ok := game.foundationFor(card); ok {
+			m = Move{Card: card}
+			m.From.Category, m.From.Index = WASTE, pos-1
+			m.To.Category, m.To.Stack = FOUNDATION, pile
+			moves = append(moves, m)
+		}
+		for to := range stacks {
+			if game.canBuildOn(to, card) {
+				m = Move{Card: card}
+				m.From.Category, m.From.Index = WASTE, pos-1
+				m.To.Category, m.To.Stack = TABLEAU, to
+				moves = append(moves, m)
+			}
+		}
+
+	// Foundation to tableau.
+	case src < pileStock:
+		pile := src - pileFoundation
+		stack := game.Foundations[pile]
+		card := top(stack)
+		if card == nil {
+			break
+		}
+		for to := range stacks {
+			if game.canBuildOn(to, card) {
+				m = Move{Card: card}
+				m.From.Category, m.From.Stack, m.From.Index = FOUNDATION, pile, len(stack)-1
+				m.To.Category, m.To.Stack = TABLEAU, to
+				moves = append(moves, m)
+			}
+		}
+
+	// Draw or recycle.
+	default:
+		if game.Stock.Pos < len(game.Stock.Stack) {
+			m = Move{Card: game.Stock.Stack[game.Stock.Pos]}
+			m.From.Category, m.From.Index = STOCK, game.Stock.Pos
+			m.To.Category = WASTE
+			moves = append(moves, m)
+		} else if game.canRecycle() {
+			m = Move{}
+			m.From.Category = WASTE
+			m.To.Category = STOCK
+			moves = append(moves, m)
+		}
+	}
+
+	return moves
+}
+
+// Call fn once for each legal move with the move made, then take it back
+// before trying the next. fn may inspect the game but must not change it.
+// Stops early if fn returns false. Observers aren't told about these moves.
+func (game *Game) EachSuccessor(fn func(m Move) bool) {
+	for _, m := range game.LegalMoves() {
+		s := game.Snapshot()
+		if err := game.play(m); err != nil {
+			log.Panicln("LegalMoves listed an illegal move:", err)
+		}
+		next := fn(m)
+		game.restore(s)
+		if !next {
+			return
+		}
+	}
+}
+
+// Make random legal moves until the game is won, no moves are left, or
+// maxMoves have been made. Returns the number of moves made.
+func (game *Game) PlayRandom(rng *rand.Rand, maxMoves int) int {
+	played := 0
+	for ; played < maxMoves && !game.IsWon(); played++ {
+		moves := game.LegalMoves()
+		if len(moves) == 0 {
+			break
+		}
+		if err := game.Apply(moves[rng.Intn(len(moves))]); err != nil {
+			log.Panicln("LegalMoves listed an illegal move:", err)
+		}
+	}
+	return played
+}
+
+// Count the tableau and waste cards that can be moved to a foundation right
+// now. Cheaper than filtering LegalMoves.
+func (game *Game) FoundationMovesAvailable() int {
+	count := 0
+	for col, stack := range game.Tableau.Stacks {
+		if !game.awaitingFlip(col) && game.canFound(top(stack)) {
+			count++
+		}
+	}
+	if pos := game.Stock.Pos; pos > 0 && game.canFound(game.Stock.Stack[pos-1]) {
+		count++
+	}
+	return count
+}
+
+// A card that can be played to a foundation, and the pile it would join.
+type FoundationTarget struct {
+	Card *Card
+	Suit CardSuit
+	Pile int // Index into Foundations.
+	From Location
+}
+
+// List the tableau and waste cards that can be moved to a foundation right
+// now, left to right with the waste last.
+func (game *Game) FoundationTargets() []FoundationTarget {
+	var targets []FoundationTarget
+	for col, stack := range game.Tableau.Stacks {
+		if pile, ok := game.foundationFor(top(stack)); ok && !game.awaitingFlip(col) {
+			card := top(stack)
+			targets = append(targets, FoundationTarget{card, card.Suit, pile, Location{TABLEAU, col, len(stack) - 1}})
+		}
+	}
+	if pos := game.Stock.Pos; pos > 0 {
+		card := game.Stock.Stack[pos-1]
+		if pile, ok := game.foundationFor(card); ok {
+			targets = append(targets, FoundationTarget{card, card.Suit, pile, Location{WASTE, 0, pos - 1}})
+		}
+	}
+	return targets
+}
+
+// Check if playing card onto a foundation pile can't cost anything: no card
+// still in play could need it to build on. That holds for the first two cards
+// of a suit, and for later ones once the opposite color's foundations hold
+// every card one rank below.
+func (game *Game) safeToFound(card *Card, pile int) bool {
+	size := len(game.Foundations[pile])
+	if size <= 1 {
+		return true
+	}
+	for other, stack := range game.foundations() {
+		suit := pileSuit(other)
+		black := suit == SPADES || suit == CLUBS
+		if black != (card.Color == BLACK) && len(stack) < size {
+			return false
+		}
+	}
+	return true
+}
+
+// Play every card that's safe to move to a foundation, as with a "collect"
+// button, until none are left. Each card is its own move in the history, so
+// they can be undone one at a time. Returns how many cards were played.
+func (game *Game) SendSafeToFoundations() int {
+	count := 0
+	for {
+		played := false
+		for _, target := range game.FoundationTargets() {
+			if !game.safeToFound(target.Card, target.Pile) {
+				continue
+			}
+			if err := game.PlayToFoundation(target.From); err != nil {
+				log.Panicln("FoundationTargets listed an illegal move:", err)
+			}
+			count++
+			played = true
+			break
+		}
+		if !played {
+			return count
+		}
+	}
+}
+
+// Finish the game the way an "auto-finish" button would: play cards to the
+// foundations, turning over facedown cards and dealing through the stock as
+// needed, until the game is won or a full pass through the stock plays
+// nothing. Cards are never moved between tableau columns. Returns whether the
+// game was won; the moves made stay in the history either way.
+func (game *Game) AutoComplete() bool {
+	idle := 0 // Stock moves since a card was last played.
+	for !game.IsWon() && idle <= len(game.Stock.Stack)+1 {
+		played := false
+		for _, m := range game.LegalMoves() {
+			if m.To.Category == FOUNDATION || m.isFlip() {
+				if err := game.Apply(m); err != nil {
+					log.Panicln("LegalMoves listed an illegal move:", err)
+				}
+				played = true
+				break
+			}
+		}
+		if played {
+			idle = 0
+			continue
+		}
+		m, ok := game.stockMove()
+		if !ok {
+			break
+		}
+		if err := game.Apply(m); err != nil {
+			log.Panicln("LegalMoves listed an illegal move:", err)
+		}
+		idle++
+	}
+	return game.IsWon()
+}
+
+// Check if AutoComplete would win from the current position, without making
+// any moves.
+func (game *Game) CanAutoComplete() bool {
+	return game.searchClone().AutoComplete()
+}
+
+// Check if every foundation pile of a suit holds all 13 cards.
+func (game *Game) FoundationComplete(suit CardSuit) bool {
+	_, ok := game.FoundationNextNeeded(suit)
+	return !ok && suit >= SPADES && suit < UNKNOWN_SUIT
+}
+
+// Get the rank a suit's foundation takes next: the lowest rank when it's
+// empty, or the rank after its top card. With two decks, the first pile of the
+// suit that isn't complete is used. Returns false once the suit is complete.
+func (game *Game) FoundationNextNeeded(suit CardSuit) (CardRank, bool) {
+	if suit < SPADES || suit >= UNKNOWN_SUIT {
+		return UNKNOWN_RANK, false
+	}
+	for pile := int(suit); pile < len(game.foundations()); pile += 4 {
+		card := top(game.Foundations[pile])
+		if card == nil {
+			return game.Variant.Order.Lowest(), true
+		}
+		if next, ok := card.Rank.Next(game.Variant.Order); ok {
+			return next, true
+		}
+	}
+	return UNKNOWN_RANK, false
+}
+
+// Check if every card has been moved to the foundations.
+func (game *Game) IsWon() bool {
+	for _, stack := range game.foundations() {
+		if len(stack) != 13 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count the facedown cards left in the tableau.
+func (game *Game) HiddenCount() int {
+	count := 0
+	for _, fd := range game.Tableau.Facedown {
+		count += fd
+	}
+	return count
+}
+
+// Get the fraction of the decks on the foundations, from 0 to 1.
+func (game *Game) FoundationProgress() float64 {
+	count := 0
+	for _, stack := range game.foundations() {
+		count += len(stack)
+	}
+	return float64(count) / float64(52*game.decks())
+}
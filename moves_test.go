@@ -0,0 +1,979 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Marshal a game without its move history or seed to JSON for comparing
+// states.
+func stateJSON(t testing.TB, game *Game) []byte {
+	t.Helper()
+	state := *game
+	state.Moves.Prev, state.Moves.Next = nil, nil
+	state.Seed = nil
+	data, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal("Marshal error:", err)
+	}
+	return data
+}
+
+// Describe a move in a short form for test output.
+func describeMove(m Move) string {
+	pile := func(category, stack int) string {
+		switch category {
+		case FOUNDATION:
+			return fmt.Sprint("f", stack)
+		case TABLEAU:
+			return fmt.Sprint("t", stack)
+		case STOCK:
+			return "s"
+		}
+		return "w"
+	}
+	card := "--"
+	if m.Card != nil {
+		card = strings.ToLower(m.Card.Id()[:1]) + m.Card.Id()[1:]
+	}
+	return card + " " + pile(m.From.Category, m.From.Stack) + ">" + pile(m.To.Category, m.To.Stack)
+}
+
+func TestLegalMoves(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	expected := []string{"d7 t0>t3", "c8 t3>t6", "d9 t6>t5", "sA s>w"}
+	moves := game.LegalMoves()
+	if len(moves) != len(expected) {
+		t.Fatalf("LegalMoves -> %d moves; expected %d: %v", len(moves), len(expected), expected)
+	}
+	for i, m := range moves {
+		if output := describeMove(m); output != expected[i] {
+			t.Errorf("LegalMoves[%d] -> %s; expected %s", i, output, expected[i])
+		}
+	}
+	// Every listed move must be accepted by Apply.
+	for _, m := range moves {
+		if err := game.Clone().Apply(m); err != nil {
+			t.Errorf("Apply(%s) -> %v", describeMove(m), err)
+		}
+	}
+}
+
+func TestMoveTableau(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if err := game.MoveTableau(3, 1, 6); err != nil {
+		t.Fatal("MoveTableau(3, 1, 6):", err)
+	}
+	if card := top(game.Tableau.Stacks[6]); card.Id() != "C8" {
+		t.Errorf("Top of column 6 is %s; expected C8", card.Id())
+	}
+	if fd := game.Tableau.Facedown[3]; fd != 2 {
+		t.Errorf("Column 3 has %d facedown cards; expected 2", fd)
+	}
+	// Move a run of two cards.
+	if err := game.MoveTableau(6, 2, 3); err == nil {
+		t.Error("Expected error moving d9 onto s9.")
+	}
+	if err := game.MoveTableau(0, 1, 6); err != nil {
+		t.Fatal("MoveTableau(0, 1, 6):", err)
+	}
+	if err := game.MoveTableau(6, 3, 5); err != nil {
+		t.Fatal("MoveTableau(6, 3, 5):", err)
+	}
+	if size := len(game.Tableau.Stacks[5]); size != 9 {
+		t.Errorf("Column 5 has %d cards; expected 9", size)
+	}
+	// Illegal moves.
+	bad := [][3]int{{1, 1, 2}, {1, 2, 5}, {2, 1, 2}, {7, 1, 0}, {0, 1, 7}}
+	for _, b := range bad {
+		if err := game.MoveTableau(b[0], b[1], b[2]); err == nil {
+			t.Errorf("Expected error from MoveTableau(%d, %d, %d).", b[0], b[1], b[2])
+		}
+	}
+}
+
+func TestMoveToFoundation(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	game.Tableau.Stacks[0] = append(game.Tableau.Stacks[0], game.Stock.Stack[0])
+	game.Stock.Stack = game.Stock.Stack[1:]
+	if err := game.MoveToFoundation(1); err == nil {
+		t.Error("Expected error moving h10 to foundation.")
+	}
+	if err := game.MoveToFoundation(0); err != nil {
+		t.Fatal("MoveToFoundation(0):", err)
+	}
+	if size := len(game.Foundations[SPADES]); size != 1 {
+		t.Errorf("Spades foundation has %d cards; expected 1", size)
+	}
+	if err := game.MoveToFoundation(4); err != nil {
+		t.Fatal("MoveToFoundation(4):", err)
+	}
+	if size := len(game.Foundations[SPADES]); size != 2 {
+		t.Errorf("Spades foundation has %d cards; expected 2", size)
+	}
+}
+
+func TestDraw(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	game.DrawCount = 3
+	game.Stock.Limit = 2
+	size := len(game.Stock.Stack)
+	for i := 0; i < 8; i++ {
+		if err := game.Draw(); err != nil {
+			t.Fatalf("Draw %d: %v", i, err)
+		}
+	}
+	if game.Stock.Pos != size {
+		t.Fatalf("Stock.Pos = %d; expected %d", game.Stock.Pos, size)
+	}
+	if err := game.Draw(); err == nil {
+		t.Error("Expected error drawing from an empty stock.")
+	}
+
+	// Recycle once, then hit the limit.
+	var recycle Move
+	recycle.From.Category = WASTE
+	recycle.To.Category = STOCK
+	if err := game.Apply(recycle); err != nil {
+		t.Fatal("Recycle:", err)
+	}
+	if game.Stock.Pos != 0 || game.Stock.Loop != 1 {
+		t.Errorf("After recycle Pos = %d, Loop = %d; expected 0, 1", game.Stock.Pos, game.Stock.Loop)
+	}
+	game.Stock.Pos = size
+	if err := game.Apply(recycle); err == nil {
+		t.Error("Expected error recycling past the limit.")
+	}
+}
+
+func TestStockAccessors(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	game.DrawCount = 3
+	size := len(game.Stock.Stack)
+	check := func(when string, remaining, waste int, exhausted bool) {
+		t.Helper()
+		if r, w, e := game.StockRemaining(), game.WasteCount(), game.StockExhausted(); r != remaining || w != waste || e != exhausted {
+			t.Errorf("%s: StockRemaining, WasteCount, StockExhausted -> %d, %d, %v; expected %d, %d, %v", when, r, w, e, remaining, waste, exhausted)
+		}
+	}
+
+	check("At the start", size, 0, false)
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	check("After a draw", size-3, 3, false)
+	// The first card drawn is at the bottom of the waste.
+	if m, _ := game.LastMove(); m.Card != game.Stock.Stack[0] {
+		t.Errorf("After a draw the waste bottom is %s; expected %s", game.Stock.Stack[0].Id(), m.Card.Id())
+	}
+	for !game.StockExhausted() {
+		if err := game.Draw(); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+	}
+	check("When exhausted", 0, size, true)
+	if err := game.RecycleStock(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	check("After recycling", size, 0, false)
+}
+
+func TestRecycleStock(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	game.Stock.Limit = 2
+	size := len(game.Stock.Stack)
+
+	// Recycling too soon is refused.
+	var illegal *IllegalMoveError
+	if err := game.RecycleStock(); !errors.As(err, &illegal) || illegal.Reason != CANNOT_RECYCLE {
+		t.Errorf("RecycleStock with cards left -> %v; expected CANNOT_RECYCLE", err)
+	} else if !strings.Contains(err.Error(), "every card is drawn") {
+		t.Errorf("RecycleStock with cards left -> %q; expected it to say the stock isn't drawn", err)
+	}
+
+	game.Stock.Pos = size
+	if err := game.RecycleStock(); err != nil {
+		t.Fatal("RecycleStock ->", err)
+	}
+	if game.Stock.Pos != 0 || game.Stock.Loop != 1 {
+		t.Errorf("After RecycleStock Pos = %d, Loop = %d; expected 0, 1", game.Stock.Pos, game.Stock.Loop)
+	}
+	if m, ok := game.LastMove(); !ok || m.To.Category != STOCK {
+		t.Errorf("RecycleStock -> last move %s; expected a recycle", describeMove(m))
+	}
+
+	game.Stock.Pos = size
+	if err := game.RecycleStock(); !errors.As(err, &illegal) || !strings.Contains(err.Error(), "all 2 passes") {
+		t.Errorf("RecycleStock past the limit -> %v; expected the pass limit error", err)
+	}
+
+	// The recycle can be taken back like any other move.
+	game.Stock.Pos = 0
+	if err := game.Undo(); err != nil || game.Stock.Pos != size || game.Stock.Loop != 0 {
+		t.Errorf("Undo of RecycleStock -> %v with Pos = %d, Loop = %d; expected nil, %d, 0", err, game.Stock.Pos, game.Stock.Loop, size)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if err := game.Undo(); err == nil {
+		t.Error("Expected error from Undo with no moves.")
+	}
+	if err := game.Redo(); err == nil {
+		t.Error("Expected error from Redo with no moves.")
+	}
+	steps := []func() error{
+		game.Draw,
+		func() error { return game.MoveTableau(3, 1, 6) },
+		func() error { return game.MoveTableau(0, 1, 6) },
+		func() error { return game.MoveTableau(6, 3, 5) },
+	}
+	states := [][]byte{stateJSON(t, game)}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Step %d: %v", i, err)
+		}
+		states = append(states, stateJSON(t, game))
+	}
+	for i := len(steps) - 1; i >= 0; i-- {
+		if err := game.Undo(); err != nil {
+			t.Fatalf("Undo %d: %v", i, err)
+		}
+		if output := stateJSON(t, game); !bytes.Equal(output, states[i]) {
+			t.Errorf("Undo %d != expected:\n\noutput:   %s\n\nexpected: %s", i, output, states[i])
+		}
+	}
+	for i := range steps {
+		if err := game.Redo(); err != nil {
+			t.Fatalf("Redo %d: %v", i, err)
+		}
+		if output := stateJSON(t, game); !bytes.Equal(output, states[i+1]) {
+			t.Errorf("Redo %d != expected:\n\noutput:   %s\n\nexpected: %s", i, output, states[i+1])
+		}
+	}
+}
+
+func TestMoveMany(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	moves := game.LegalMoves()
+	valid := []Move{moves[1], moves[3]} // c8 t3>t6, then draw the ace of spades.
+	illegal := moves[0]
+	illegal.To.Category, illegal.To.Stack = FOUNDATION, 0
+
+	if applied, err := game.MoveMany(valid); err != nil || applied != 2 {
+		t.Errorf("MoveMany -> %d, %v; expected 2, nil", applied, err)
+	}
+	if len(game.Moves.Prev) != 2 {
+		t.Errorf("MoveMany -> %d moves in history; expected 2", len(game.Moves.Prev))
+	}
+
+	game = loadTestGame(t, "game.toml")
+	applied, err := game.MoveMany([]Move{valid[0], illegal, valid[1]})
+	var illegalErr *IllegalMoveError
+	if applied != 1 || !errors.As(err, &illegalErr) {
+		t.Errorf("MoveMany with an illegal move -> %d, %v; expected 1, IllegalMoveError", applied, err)
+	}
+	if len(game.Moves.Prev) != 1 {
+		t.Errorf("MoveMany with an illegal move -> %d moves in history; expected 1", len(game.Moves.Prev))
+	}
+
+	game = loadTestGame(t, "game.toml")
+	before := stateJSON(t, game)
+	if err := game.MoveManyAtomic([]Move{valid[0], illegal, valid[1]}); err == nil {
+		t.Error("MoveManyAtomic with an illegal move -> nil; expected error")
+	}
+	if output := stateJSON(t, game); !bytes.Equal(output, before) || len(game.Moves.Prev) != 0 {
+		t.Errorf("MoveManyAtomic with an illegal move -> changed game:\n%s\nexpected:\n%s", output, before)
+	}
+	if err := game.MoveManyAtomic(valid); err != nil || len(game.Moves.Prev) != 2 {
+		t.Errorf("MoveManyAtomic -> %v with %d moves; expected nil with 2", err, len(game.Moves.Prev))
+	}
+}
+
+func TestPlayToFoundation(t *testing.T) {
+	// The ace of hearts tops column 0 and the ace of spades is in the waste.
+	ranks := []string{"K", "Q", "J", "10", "9", "8", "7", "6", "5", "4", "3", "2", "A"}
+	var hearts, spades []string
+	for _, rank := range ranks {
+		hearts = append(hearts, "h"+rank)
+		if rank != "A" {
+			spades = append(spades, "s"+rank)
+		}
+	}
+	game := endgame(t, hearts, spades)
+	game.Stock.Stack = game.Foundations[SPADES]
+	game.Stock.Pos = 1
+	game.Foundations[SPADES] = []*Card{}
+
+	if err := game.PlayToFoundation(Location{TABLEAU, 0, 12}); err != nil {
+		t.Fatal("PlayToFoundation(hA) ->", err)
+	}
+	if err := game.PlayToFoundation(Location{WASTE, 0, 0}); err != nil {
+		t.Fatal("PlayToFoundation(sA) ->", err)
+	}
+	for pile, expected := range map[CardSuit]string{HEARTS: "HA", SPADES: "SA"} {
+		if card := top(game.Foundations[pile]); card == nil || card.Id() != expected {
+			t.Errorf("Foundation %d top -> %v; expected %s", pile, card, expected)
+		}
+	}
+
+	// Only the top card of a column can go to a foundation.
+	var illegal *IllegalMoveError
+	if err := game.PlayToFoundation(Location{TABLEAU, 1, 0}); !errors.As(err, &illegal) {
+		t.Errorf("PlayToFoundation(sK) -> %v; expected an illegal move", err)
+	}
+}
+
+func TestColumnRange(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if count := game.ColumnCount(); count != 7 {
+		t.Errorf("ColumnCount -> %d; expected 7", count)
+	}
+	for _, cols := range [][2]int{{7, 0}, {-1, 0}, {0, 7}} {
+		err := game.MoveTableau(cols[0], 1, cols[1])
+		var illegal *IllegalMoveError
+		if !errors.As(err, &illegal) || illegal.Reason != NO_SUCH_PILE || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("MoveTableau(%d, 1, %d) -> %v; expected an out of range error", cols[0], cols[1], err)
+		}
+	}
+}
+
+func TestIsReversible(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	expected := map[string]bool{
+		"d7 t0>t3": true,  // Column 0 is a lone face-up card.
+		"c8 t3>t6": false, // Uncovers a facedown card.
+		"d9 t6>t5": false,
+		"sA s>w":   true,
+	}
+	for _, m := range game.LegalMoves() {
+		if output := game.IsReversible(m); output != expected[describeMove(m)] {
+			t.Errorf("IsReversible(%s) -> %v; expected %v", describeMove(m), output, expected[describeMove(m)])
+		}
+	}
+
+	var recycle Move
+	recycle.From.Category = WASTE
+	recycle.To.Category = STOCK
+	if game.IsReversible(recycle) {
+		t.Error("IsReversible(recycle) -> true; expected false")
+	}
+}
+
+func TestPlayRandom(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		game := NewDeal(seed)
+		rng := rand.New(rand.NewSource(seed))
+		total := 0
+		for i := 0; i < 300; i++ {
+			played := game.PlayRandom(rng, 1)
+			if played == 0 {
+				break
+			}
+			total += played
+			if err := game.Validate(); err != nil {
+				t.Fatalf("Seed %d, move %d: Validate -> %v", seed, total, err)
+			}
+		}
+		if total == 0 {
+			t.Errorf("Seed %d: PlayRandom made no moves", seed)
+		}
+		if played := NewDeal(seed).PlayRandom(rng, 50); played != 50 {
+			t.Errorf("Seed %d: PlayRandom(50) -> %d; expected 50", seed, played)
+		}
+	}
+}
+
+func TestFoundationNextNeeded(t *testing.T) {
+	// Hearts are partly built, spades are complete, and the rest are empty.
+	game := new(Game)
+	for rank := ACE; rank <= KING; rank++ {
+		game.Foundations[SPADES] = append(game.Foundations[SPADES], NewCard(rank, SPADES))
+		if rank <= FOUR {
+			game.Foundations[HEARTS] = append(game.Foundations[HEARTS], NewCard(rank, HEARTS))
+		}
+	}
+	tests := []struct {
+		suit     CardSuit
+		rank     CardRank
+		ok       bool
+		complete bool
+	}{
+		{SPADES, UNKNOWN_RANK, false, true},
+		{HEARTS, FIVE, true, false},
+		{CLUBS, ACE, true, false},
+		{UNKNOWN_SUIT, UNKNOWN_RANK, false, false},
+	}
+	for _, test := range tests {
+		if rank, ok := game.FoundationNextNeeded(test.suit); rank != test.rank || ok != test.ok {
+			t.Errorf("FoundationNextNeeded(%v) -> %v, %v; expected %v, %v", test.suit, rank, ok, test.rank, test.ok)
+		}
+		if complete := game.FoundationComplete(test.suit); complete != test.complete {
+			t.Errorf("FoundationComplete(%v) -> %v; expected %v", test.suit, complete, test.complete)
+		}
+	}
+
+	// With two decks, spades still need a second run.
+	game.Decks = 2
+	if rank, ok := game.FoundationNextNeeded(SPADES); rank != ACE || !ok {
+		t.Errorf("FoundationNextNeeded(spades) with two decks -> %v, %v; expected ace, true", rank, ok)
+	}
+}
+
+func TestEachSuccessor(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	before := stateJSON(t, game)
+	var seen []string
+	game.EachSuccessor(func(m Move) bool {
+		seen = append(seen, describeMove(m))
+		if last, _ := game.LastMove(); describeMove(last) != describeMove(m) {
+			t.Errorf("EachSuccessor(%s) -> last move %s; expected the move made", describeMove(m), describeMove(last))
+		}
+		return true
+	})
+	if len(seen) != len(game.LegalMoves()) {
+		t.Errorf("EachSuccessor -> %d successors %v; expected %d", len(seen), seen, len(game.LegalMoves()))
+	}
+	if output := stateJSON(t, game); !bytes.Equal(output, before) || game.UndoDepth() != 0 {
+		t.Error("EachSuccessor changed the game.")
+	}
+
+	count := 0
+	game.EachSuccessor(func(m Move) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Errorf("EachSuccessor stopping after 2 -> %d calls; expected 2", count)
+	}
+	if output := stateJSON(t, game); !bytes.Equal(output, before) || game.UndoDepth() != 0 {
+		t.Error("EachSuccessor stopping early changed the game.")
+	}
+}
+
+func TestSeek(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	moves := []func(g *Game) error{
+		(*Game).Draw,
+		func(g *Game) error { return g.MoveTableau(3, 1, 6) },
+		func(g *Game) error { return g.MoveTableau(0, 1, 6) },
+		func(g *Game) error { return g.MoveTableau(6, 3, 5) },
+		(*Game).Draw,
+	}
+	initial := stateJSON(t, game)
+	for i, move := range moves {
+		if err := move(game); err != nil {
+			t.Fatalf("Setup move %d: %v", i, err)
+		}
+	}
+	final := stateJSON(t, game)
+
+	game.UndoAll()
+	if output := stateJSON(t, game); !bytes.Equal(output, initial) {
+		t.Errorf("UndoAll != initial deal:\n\noutput:   %s\n\nexpected: %s", output, initial)
+	}
+	if err := game.RedoAll(); err != nil {
+		t.Fatal("RedoAll:", err)
+	}
+	if output := stateJSON(t, game); !bytes.Equal(output, final) {
+		t.Errorf("RedoAll != final:\n\noutput:   %s\n\nexpected: %s", output, final)
+	}
+
+	// Seeking back and forth must match replaying the first k moves.
+	for _, k := range []int{2, 0, 5, 3, 1, 4} {
+		replay := loadTestGame(t, "game.toml")
+		for i := 0; i < k; i++ {
+			moves[i](replay)
+		}
+		if err := game.Seek(k); err != nil {
+			t.Fatalf("Seek(%d): %v", k, err)
+		}
+		if output, expected := stateJSON(t, game), stateJSON(t, replay); !bytes.Equal(output, expected) {
+			t.Errorf("Seek(%d) != replay:\n\noutput:   %s\n\nexpected: %s", k, output, expected)
+		}
+	}
+	for _, k := range []int{-1, 6} {
+		if err := game.Seek(k); err == nil {
+			t.Errorf("Expected error from Seek(%d).", k)
+		}
+	}
+}
+
+func TestMoveTableauFaceup(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	// Column 5 has a single face-up card over five facedown ones.
+	err := game.MoveTableau(5, 2, 0)
+	if err == nil {
+		t.Fatal("Expected error moving a facedown card.")
+	}
+	expected := "Only 1 face-up cards available in tableau column 5, requested 2."
+	if err.Error() != expected {
+		t.Errorf("MoveTableau(5, 2, 0) -> %q; expected %q", err, expected)
+	}
+	// More cards than the column holds.
+	if err := game.MoveTableau(0, 3, 3); err == nil {
+		t.Error("Expected error moving more cards than column 0 holds.")
+	}
+}
+
+func TestFoundationMovesAvailable(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if count := game.FoundationMovesAvailable(); count != 0 {
+		t.Errorf("FoundationMovesAvailable -> %d; expected 0", count)
+	}
+	// Move sA onto column 0 and draw up to hA.
+	game.Tableau.Stacks[0] = append(game.Tableau.Stacks[0], game.Stock.Stack[0])
+	game.Stock.Stack = game.Stock.Stack[1:]
+	for game.Stock.Stack[game.Stock.Pos].Id() != "HA" {
+		game.Stock.Pos++
+	}
+	game.Stock.Pos++
+	if count := game.FoundationMovesAvailable(); count != 2 {
+		t.Errorf("FoundationMovesAvailable -> %d; expected 2", count)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { game.FoundationMovesAvailable() }); allocs != 0 {
+		t.Errorf("FoundationMovesAvailable allocated %v times; expected 0", allocs)
+	}
+}
+
+func TestMoveRunAuto(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if err := game.MoveTableau(3, 1, 6); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.MoveTableau(0, 1, 6); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	// Column 6 ends in d9 c8 d7; move the run from the nine.
+	if err := game.MoveRunAuto(6, NINE, 5); err != nil {
+		t.Fatal("MoveRunAuto(6, NINE, 5):", err)
+	}
+	expected := []string{"??", "??", "??", "??", "SJ", "S10", "D9", "C8", "D7"}
+	stack := game.Tableau.Stacks[5]
+	if len(stack) != len(expected) {
+		t.Fatalf("Column 5 has %d cards; expected %d", len(stack), len(expected))
+	}
+	for i, card := range stack {
+		if card.Id() != expected[i] {
+			t.Errorf("Column 5 card %d is %s; expected %s", i, card.Id(), expected[i])
+		}
+	}
+
+	// No such face-up rank, a facedown rank, and a broken run.
+	if err := game.MoveRunAuto(6, QUEEN, 1); err == nil {
+		t.Error("Expected error moving a missing rank.")
+	}
+	if err := game.MoveRunAuto(6, KING, 0); err == nil {
+		t.Error("Expected error moving a facedown rank.")
+	}
+	if err := game.MoveRunAuto(2, EIGHT, 1); err == nil {
+		t.Error("Expected error moving a facedown eight.")
+	}
+	game.Tableau.Facedown[2] = 0
+	if err := game.MoveRunAuto(2, EIGHT, 1); err == nil {
+		t.Error("Expected error moving a broken run.")
+	}
+}
+
+func TestPreview(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	original := gameJSON(t, game)
+	m := game.LegalMoves()[1] // c8 t3>t6
+
+	preview, err := game.Preview(m)
+	if err != nil {
+		t.Fatal("Preview:", err)
+	}
+	if output := gameJSON(t, game); !bytes.Equal(output, original) {
+		t.Errorf("Preview changed the game:\n\noutput:   %s\n\nexpected: %s", output, original)
+	}
+	expected := game.Clone()
+	if err := expected.MoveTableau(3, 1, 6); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if output, exp := stateJSON(t, preview), stateJSON(t, expected); !bytes.Equal(output, exp) {
+		t.Errorf("Preview != expected:\n\noutput:   %s\n\nexpected: %s", output, exp)
+	}
+
+	// Illegal moves return an error and no game.
+	m.To.Stack = 0
+	if preview, err := game.Preview(m); err == nil || preview != nil {
+		t.Error("Expected error previewing an illegal move.")
+	}
+}
+
+func TestFoundationTargets(t *testing.T) {
+	// The ace of spades and two of hearts top the first two columns.
+	ranks := []string{"K", "Q", "J", "10", "9", "8", "7", "6", "5", "4", "3", "2", "A"}
+	var spades, hearts []string
+	for _, rank := range ranks {
+		spades = append(spades, "s"+rank)
+		if rank != "A" {
+			hearts = append(hearts, "h"+rank)
+		}
+	}
+	game := endgame(t, spades, hearts)
+	targets := game.FoundationTargets()
+	expected := []FoundationTarget{
+		{mustParseCards(t, "sA")[0], SPADES, 0, Location{TABLEAU, 0, 12}},
+		{mustParseCards(t, "h2")[0], HEARTS, 2, Location{TABLEAU, 1, 11}},
+	}
+	if len(targets) != len(expected) {
+		t.Fatalf("FoundationTargets -> %d targets; expected %d", len(targets), len(expected))
+	}
+	for i := range expected {
+		if targets[i] != expected[i] {
+			t.Errorf("FoundationTargets[%d] -> %+v; expected %+v", i, targets[i], expected[i])
+		}
+	}
+
+	if targets := loadTestGame(t, "game.toml").FoundationTargets(); len(targets) != 0 {
+		t.Errorf("FoundationTargets -> %d targets; expected none", len(targets))
+	}
+}
+
+func TestHiddenCountProgress(t *testing.T) {
+	game := NewDeal(1)
+	if output := game.HiddenCount(); output != 21 {
+		t.Errorf("HiddenCount() on new deal -> %d; expected 21", output)
+	}
+	if output := game.FoundationProgress(); output != 0 {
+		t.Errorf("FoundationProgress() on new deal -> %v; expected 0", output)
+	}
+
+	game = endgame(t, []string{"sK", "hK"}, []string{"cK"}, []string{"dK"})
+	game.Tableau.Facedown[0] = 1
+	if output := game.HiddenCount(); output != 1 {
+		t.Errorf("HiddenCount() on endgame -> %d; expected 1", output)
+	}
+	if output, expected := game.FoundationProgress(), 48.0/52; output != expected {
+		t.Errorf("FoundationProgress() on endgame -> %v; expected %v", output, expected)
+	}
+}
+
+func TestIllegalMoveReason(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if err := game.MoveTableau(0, 1, 3); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	tests := []struct {
+		name     string
+		move     func() error
+		expected IllegalMoveReason
+	}{
+		{"d9 onto h10", func() error { return game.MoveTableau(6, 1, 1) }, COLOR_MISMATCH},
+		{"h5 onto s10", func() error { return game.MoveTableau(2, 1, 5) }, RANK_GAP},
+		{"d7 onto empty column", func() error { return game.MoveTableau(3, 1, 0) }, NOT_KING_ON_EMPTY},
+		{"h5 to foundation", func() error { return game.MoveToFoundation(2) }, NOT_ACE_ON_EMPTY},
+		{"s10 onto its own column", func() error { return game.MoveTableau(5, 1, 5) }, SAME_COLUMN},
+		{"facedown run onto its own column", func() error { return game.MoveTableau(6, 5, 6) }, SAME_COLUMN},
+		{"no cards", func() error { return game.MoveTableau(5, 0, 3) }, NO_CARDS},
+		{"negative count", func() error { return game.MoveTableau(5, -2, 3) }, NO_CARDS},
+	}
+	before := stateJSON(t, game)
+	for _, test := range tests {
+		err := test.move()
+		var illegal *IllegalMoveError
+		if !errors.As(err, &illegal) {
+			t.Errorf("%s -> %v; expected *IllegalMoveError", test.name, err)
+		} else if illegal.Reason != test.expected {
+			t.Errorf("%s -> reason %d (%s); expected %d", test.name, illegal.Reason, illegal.Message, test.expected)
+		}
+	}
+	if output := stateJSON(t, game); !bytes.Equal(output, before) {
+		t.Error("Illegal moves changed the game.")
+	}
+}
+
+func TestCanDrop(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	// Move c8 from column 3 to build d9 c8 h7 in column 6, and start column 0
+	// with a black ten.
+	game.Tableau.Stacks[6] = append(game.Tableau.Stacks[6], mustParseCards(t, "c8", "h7")...)
+	game.Tableau.Stacks[3] = game.Tableau.Stacks[3][:3]
+	game.Tableau.Stacks[0] = mustParseCards(t, "s10")
+	d9 := mustParseCards(t, "d9")[0]
+	c8 := mustParseCards(t, "c8")[0]
+
+	// The dragged d9 is mid-run and the run fits onto s10.
+	if !game.CanDrop(6, d9, 0) {
+		t.Error("CanDrop(6, d9, 0) -> false; expected true")
+	}
+	// The c8 isn't in column 3 anymore, and d9 doesn't fit on h10.
+	if game.CanDrop(3, c8, 0) {
+		t.Error("CanDrop(3, c8, 0) -> true; expected false")
+	}
+	if game.CanDrop(6, d9, 1) {
+		t.Error("CanDrop(6, d9, 1) -> true; expected false")
+	}
+
+	// Break the sequence above d9.
+	game.Tableau.Stacks[6][len(game.Tableau.Stacks[6])-1] = mustParseCards(t, "s6")[0]
+	if game.CanDrop(6, d9, 0) {
+		t.Error("CanDrop(6, d9, 0) below a broken sequence -> true; expected false")
+	}
+	// Facedown cards can't be dragged.
+	if game.CanDrop(6, mustParseCards(t, "hK")[0], 0) {
+		t.Error("CanDrop(6, hK, 0) -> true; expected false")
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if _, ok := game.LastMove(); ok {
+		t.Error("LastMove() on new game -> true; expected false")
+	}
+	if err := game.Annotate("Too early."); err == nil {
+		t.Error("Expected error from Annotate with no moves.")
+	}
+
+	if err := game.MoveTableau(3, 1, 6); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	last, ok := game.LastMove()
+	if !ok {
+		t.Fatal("LastMove() -> false; expected true")
+	}
+	if output := describeMove(last); output != "c8 t3>t6" {
+		t.Errorf("LastMove() -> %s; expected c8 t3>t6", output)
+	}
+	if err := game.Annotate("Uncovers s9."); err != nil {
+		t.Fatal("Annotate:", err)
+	}
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+
+	// The note survives undo and redo.
+	game.Undo()
+	game.Undo()
+	game.RedoAll()
+	history := game.History()
+	if len(history) != 2 {
+		t.Fatalf("History() has %d moves; expected 2", len(history))
+	}
+	if history[0].Note != "Uncovers s9." || history[1].Note != "" {
+		t.Errorf("History() notes -> %q, %q; expected \"Uncovers s9.\", \"\"", history[0].Note, history[1].Note)
+	}
+}
+
+func TestMovesSince(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	for i := 0; i < 3; i++ {
+		moves := game.LegalMoves()
+		if len(moves) == 0 {
+			t.Fatal("Setup error: no legal moves")
+		}
+		if err := game.Apply(moves[0]); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+	}
+
+	history := game.History()
+	since := game.MovesSince(1)
+	if len(since) != 2 || describeMove(since[0]) != describeMove(history[1]) || describeMove(since[1]) != describeMove(history[2]) {
+		t.Errorf("MovesSince(1) -> %v; expected %v", since, history[1:])
+	}
+	if since := game.MovesSince(3); len(since) != 0 {
+		t.Errorf("MovesSince(3) -> %v; expected none", since)
+	}
+	if since := game.MovesSince(-1); len(since) != 3 {
+		t.Errorf("MovesSince(-1) -> %d moves; expected 3", len(since))
+	}
+}
+
+func TestCanUndoRedo(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	check := func(when string, canUndo, canRedo bool, undoDepth, redoDepth int) {
+		t.Helper()
+		if output := game.CanUndo(); output != canUndo {
+			t.Errorf("CanUndo() %s -> %v; expected %v", when, output, canUndo)
+		}
+		if output := game.CanRedo(); output != canRedo {
+			t.Errorf("CanRedo() %s -> %v; expected %v", when, output, canRedo)
+		}
+		if output := game.UndoDepth(); output != undoDepth {
+			t.Errorf("UndoDepth() %s -> %d; expected %d", when, output, undoDepth)
+		}
+		if output := game.RedoDepth(); output != redoDepth {
+			t.Errorf("RedoDepth() %s -> %d; expected %d", when, output, redoDepth)
+		}
+	}
+	check("with no history", false, false, 0, 0)
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.MoveTableau(3, 1, 6); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	check("after two moves", true, false, 2, 0)
+	game.Undo()
+	check("after undo", true, true, 1, 1)
+	game.Undo()
+	check("after undoing everything", false, true, 0, 2)
+}
+
+func TestFlip(t *testing.T) {
+	spec := "stock: DK\nfoundations: *\nDQ SK | HK"
+	var illegal *IllegalMoveError
+
+	// Uncovered cards turn over by themselves by default.
+	game, err := BuildGame(spec)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.MoveTableau(0, 1, 2); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if fd := game.Tableau.Facedown[0]; fd != 1 {
+		t.Errorf("Auto flip left %d facedown; expected 1", fd)
+	}
+	if err := game.Flip(0); !errors.As(err, &illegal) || illegal.Reason != NOTHING_TO_FLIP {
+		t.Errorf("Flip(0) after auto flip -> %v; expected NOTHING_TO_FLIP", err)
+	}
+
+	// With manual flips, the spade king stays facedown until flipped.
+	game, err = BuildGame(spec)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	game.Variant.ManualFlip = true
+	if err := game.MoveTableau(0, 1, 2); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if fd := game.Tableau.Facedown[0]; fd != 2 {
+		t.Errorf("Manual flip left %d facedown; expected 2", fd)
+	}
+	if err := game.Validate(); err != nil {
+		t.Errorf("Validate() awaiting a flip -> %v; expected nil", err)
+	}
+	if err := game.MoveToFoundation(0); !errors.As(err, &illegal) || illegal.Reason != FACEDOWN_CARD {
+		t.Errorf("MoveToFoundation(0) before flip -> %v; expected FACEDOWN_CARD", err)
+	}
+	var listed bool
+	for _, m := range game.LegalMoves() {
+		listed = listed || m.isFlip() && m.From.Stack == 0
+		if m.From.Category == TABLEAU && m.From.Stack == 0 && !m.isFlip() {
+			t.Errorf("LegalMoves() before flip listed %s", m.Notation())
+		}
+	}
+	if !listed {
+		t.Error("LegalMoves() before flip did not list the flip")
+	}
+
+	if err := game.Flip(0); err != nil {
+		t.Fatal("Flip(0):", err)
+	}
+	if fd := game.Tableau.Facedown[0]; fd != 1 {
+		t.Errorf("Flip(0) left %d facedown; expected 1", fd)
+	}
+	if err := game.Flip(0); !errors.As(err, &illegal) || illegal.Reason != NOTHING_TO_FLIP {
+		t.Errorf("Flip(0) twice -> %v; expected NOTHING_TO_FLIP", err)
+	}
+	if err := game.MoveToFoundation(0); err != nil {
+		t.Errorf("MoveToFoundation(0) after flip -> %v; expected nil", err)
+	}
+
+	// Undo turns the card back over.
+	game.UndoAll()
+	if fd := game.Tableau.Facedown[0]; fd != 2 || len(game.Tableau.Stacks[0]) != 3 {
+		t.Errorf("UndoAll() -> %d of %d facedown; expected 2 of 3", fd, len(game.Tableau.Stacks[0]))
+	}
+}
+
+func TestSendSafeToFoundations(t *testing.T) {
+	// The clubs foundation holds only its ace, so the hearts can go up to the
+	// three once the two of clubs joins it, but not the four.
+	game, err := BuildGame(`
+		stock: | H5 H6 H7 H8 H9 H10 HJ HQ HK C3 C4 C5 C6 C7 C8 C9 C10 CJ CQ CK
+		foundations: *
+		HA
+		H2
+		H3
+		C2
+		H4
+	`)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if output := game.SendSafeToFoundations(); output != 4 {
+		t.Errorf("SendSafeToFoundations() -> %d; expected 4", output)
+	}
+	if len(game.Tableau.Stacks[4]) != 1 {
+		t.Error("SendSafeToFoundations() played the unsafe H4")
+	}
+	if output := game.SendSafeToFoundations(); output != 0 {
+		t.Errorf("SendSafeToFoundations() again -> %d; expected 0", output)
+	}
+
+	// Each card comes back with its own undo.
+	for i := 4; i > 0; i-- {
+		if err := game.Undo(); err != nil {
+			t.Fatalf("Undo %d: %v", i, err)
+		}
+		// The clubs foundation started with its ace.
+		if output := len(game.Foundations[HEARTS]) + len(game.Foundations[CLUBS]) - 1; output != i-1 {
+			t.Errorf("After undo %d, %d safe cards on the foundations; expected %d", 5-i, output, i-1)
+		}
+	}
+}
+
+func TestCanAutoComplete(t *testing.T) {
+	// Every card left can go straight up, drawing three at a time.
+	game, err := BuildGame(`
+		stock: | CJ HQ HJ CQ
+		foundations: *
+		HK
+		CK
+	`)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	game.DrawCount = 3
+	before := stateJSON(t, game)
+	if !game.CanAutoComplete() {
+		t.Error("CanAutoComplete() -> false; expected true")
+	}
+	if output := stateJSON(t, game); !bytes.Equal(output, before) || len(game.Moves.Prev) != 0 {
+		t.Error("CanAutoComplete() changed the game.")
+	}
+	if !game.AutoComplete() || !game.IsWon() {
+		t.Error("AutoComplete() -> false; expected a won game")
+	}
+
+	// Three cards short, but the queen sits on the jack it needs first.
+	game, err = BuildGame(`
+		foundations: *
+		HJ HQ
+		HK
+	`)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if game.CanAutoComplete() {
+		t.Error("CanAutoComplete() with a buried card -> true; expected false")
+	}
+
+	// Drawing one at a time, the jack plays on the first pass but the queen
+	// and king need a second.
+	game, err = BuildGame(`
+		stock: | HQ HK HJ
+		foundations: *
+	`)
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	game.Stock.Limit = 1
+	if game.CanAutoComplete() {
+		t.Error("CanAutoComplete() with one pass -> true; expected false")
+	}
+	game.Stock.Limit = 0
+	if !game.CanAutoComplete() {
+		t.Error("CanAutoComplete() with unlimited passes -> false; expected true")
+	}
+}
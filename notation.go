@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Move notation is the moved card's code followed by its source and
+// destination piles, as in "C8 t3>t6". Piles are written t0 to t6 for the
+// tableau columns, f0 and up for the foundations, s for the stock, and w for
+// the waste. Recycling the waste moves no single card, so its card is "--".
+// Flipping a facedown card in place names its column twice, as in "C8 t3>t3".
+
+// Write a pile in move notation.
+func pileNotation(category, stack int) string {
+	switch category {
+	case TABLEAU:
+		return "t" + strconv.Itoa(stack)
+	case FOUNDATION:
+		return "f" + strconv.Itoa(stack)
+	case STOCK:
+		return "s"
+	}
+	return "w"
+}
+
+// Write the move in move notation.
+func (m Move) Notation() string {
+	card := "--"
+	if m.Card != nil {
+		card = m.Card.Id()
+	}
+	return card + " " + pileNotation(m.From.Category, m.From.Stack) + ">" + pileNotation(m.To.Category, m.To.Stack)
+}
+
+// Read a pile written in move notation.
+func parsePile(s string) (category, stack int, ok bool) {
+	switch {
+	case s == "s":
+		return STOCK, 0, true
+	case s == "w":
+		return WASTE, 0, true
+	case len(s) < 2:
+		return 0, 0, false
+	case s[0] == 't':
+		category = TABLEAU
+	case s[0] == 'f':
+		category = FOUNDATION
+	default:
+		return 0, 0, false
+	}
+	stack, err := strconv.Atoi(s[1:])
+	return category, stack, err == nil && stack >= 0
+}
+
+// Read a move written in move notation, filling in where the card sits in
+// its source pile from the current position. The move isn't checked for
+// legality; Apply does that.
+func (game *Game) ParseMove(s string) (Move, error) {
+	var m Move
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return m, fmt.Errorf("Invalid move %q.", s)
+	}
+	from, to, found := strings.Cut(fields[1], ">")
+	var ok bool
+	if m.From.Category, m.From.Stack, ok = parsePile(from); !ok || !found {
+		return m, fmt.Errorf("Invalid move %q.", s)
+	}
+	if m.To.Category, m.To.Stack, ok = parsePile(to); !ok {
+		return m, fmt.Errorf("Invalid move %q.", s)
+	}
+	if fields[0] != "--" {
+		card, err := ParseCard(fields[0])
+		if err != nil {
+			return m, err
+		}
+		m.Card = card
+	}
+
+	switch m.From.Category {
+	case TABLEAU:
+		if m.From.Stack < game.ColumnCount() {
+			m.From.Index = -1
+			for i, card := range game.Tableau.Stacks[m.From.Stack] {
+				if card == m.Card {
+					m.From.Index = i
+				}
+			}
+		}
+	case FOUNDATION:
+		if m.From.Stack < len(game.foundations()) {
+			m.From.Index = len(game.Foundations[m.From.Stack]) - 1
+		}
+	case STOCK:
+		m.From.Index = game.Stock.Pos
+	case WASTE:
+		if m.To.Category != STOCK {
+			m.From.Index = game.Stock.Pos - 1
+		}
+	}
+	return m, nil
+}
+
+// Write the game's move history as a solution string, one move per line.
+func (game *Game) ExportSolution() string {
+	var b strings.Builder
+	for _, m := range game.Moves.Prev {
+		b.WriteString(m.Notation())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Load a game from its starting position and replay a solution string on it.
+// Moves may be separated by newlines or semicolons.
+func ImportSolution(start *SaveData, s string) (*Game, error) {
+	game, err := NewGameFromSave(start)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '\n' || r == ';'
+	})
+	n := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		n++
+		m, err := game.ParseMove(line)
+		if err != nil {
+			return nil, fmt.Errorf("Move %d of solution: %w", n, err)
+		}
+		if err := game.Apply(m); err != nil {
+			return nil, fmt.Errorf("Move %d of solution: %w", n, err)
+		}
+	}
+	return game, nil
+}
+
+// Check a solution string against a deal. Returns whether the moves win the
+// game; an illegal or unreadable move is an error naming its move number.
+func VerifySolution(deal *SaveData, solution string) (bool, error) {
+	game, err := ImportSolution(deal, solution)
+	if err != nil {
+		return false, err
+	}
+	return game.IsWon(), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMove(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	for _, m := range game.LegalMoves() {
+		parsed, err := game.ParseMove(m.Notation())
+		if err != nil {
+			t.Errorf("ParseMove(%q) -> %v", m.Notation(), err)
+		} else if parsed != m {
+			t.Errorf("ParseMove(%q) -> %s; expected %s", m.Notation(), describeMove(parsed), describeMove(m))
+		}
+	}
+	for _, input := range []string{"", "C8", "C8 t3", "C8 x3>t6", "C8 t3>t", "ZZ t3>t6"} {
+		if _, err := game.ParseMove(input); err == nil {
+			t.Errorf("ParseMove(%q) -> nil; expected error", input)
+		}
+	}
+}
+
+func TestExportSolution(t *testing.T) {
+	game := NewDeal(2)
+	start := game.Export()
+	solution, ok := game.Solve(defaultSolveStates)
+	if !ok {
+		t.Fatal("Setup error: no solution for seed 2")
+	}
+	for _, m := range solution {
+		if err := game.Apply(m); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+	}
+
+	exported := game.ExportSolution()
+	replay, err := ImportSolution(start, exported)
+	if err != nil {
+		t.Fatal("ImportSolution ->", err)
+	}
+	if !replay.IsWon() {
+		t.Error("ImportSolution -> game not won; expected a win")
+	}
+
+	// Semicolons separate moves as well as newlines.
+	replay, err = ImportSolution(start, strings.ReplaceAll(exported, "\n", "; "))
+	if err != nil || !replay.IsWon() {
+		t.Errorf("ImportSolution with semicolons -> %v; expected a win", err)
+	}
+
+	if _, err := ImportSolution(start, "SA t0>f0"); err == nil {
+		t.Error("ImportSolution with an illegal move -> nil; expected error")
+	}
+}
+
+func TestVerifySolution(t *testing.T) {
+	game := NewDeal(2)
+	deal := game.Export()
+	solution, ok := game.Solve(defaultSolveStates)
+	if !ok {
+		t.Fatal("Setup error: no solution for seed 2")
+	}
+	if _, err := game.MoveMany(solution); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	moves := strings.Split(strings.TrimSpace(game.ExportSolution()), "\n")
+
+	if won, err := VerifySolution(deal, strings.Join(moves, "\n")); !won || err != nil {
+		t.Errorf("VerifySolution(full solution) -> %v, %v; expected true, nil", won, err)
+	}
+	if won, err := VerifySolution(deal, strings.Join(moves[:10], "\n")); won || err != nil {
+		t.Errorf("VerifySolution(first 10 moves) -> %v, %v; expected false, nil", won, err)
+	}
+
+	// Swap in a move that can't be made at that point.
+	broken := append([]string(nil), moves...)
+	broken[4] = "SK t0>f0"
+	won, err := VerifySolution(deal, strings.Join(broken, "\n"))
+	if won || err == nil || !strings.HasPrefix(err.Error(), "Move 5 of solution:") {
+		t.Errorf("VerifySolution(illegal move 5) -> %v, %v; expected false and a move 5 error", won, err)
+	}
+}
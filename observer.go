@@ -0,0 +1,59 @@
+package main
+
+// An Observer is told about changes to a game it's subscribed to, so a UI can
+// update without polling. Callbacks run after the change is made, with no
+// locks held, so they may read or change the game themselves.
+type Observer interface {
+	OnMove(m Move) // A move was applied or redone.
+	OnUndo()       // A move was taken back.
+	OnWin()        // A move just put every card on the foundations.
+}
+
+// Tell o about changes to the game from now on. Observers are told in the
+// order they subscribed. Clones start with no observers.
+func (game *Game) Subscribe(o Observer) {
+	game.observers = append(game.observers, o)
+}
+
+// Stop telling o about changes. Observers are compared with ==, so they
+// should be pointers or other comparable values.
+func (game *Game) Unsubscribe(o Observer) {
+	for i, other := range game.observers {
+		if other == o {
+			game.observers = append(game.observers[:i:i], game.observers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Tell observers about a move, and about the win if it was the last one.
+func (game *Game) notifyMove(m Move) {
+	game.notifyMoves([]Move{m})
+}
+
+// Tell observers about moves made in order, then about the win if the last
+// one won the game.
+func (game *Game) notifyMoves(moves []Move) {
+	if len(game.observers) == 0 || len(moves) == 0 {
+		return
+	}
+	// Iterate over a copy in case an observer unsubscribes.
+	observers := append([]Observer(nil), game.observers...)
+	for _, m := range moves {
+		for _, o := range observers {
+			o.OnMove(m)
+		}
+	}
+	if game.IsWon() {
+		for _, o := range observers {
+			o.OnWin()
+		}
+	}
+}
+
+// Tell observers a move was taken back.
+func (game *Game) notifyUndo() {
+	for _, o := range append([]Observer(nil), game.observers...) {
+		o.OnUndo()
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Records the callbacks it receives. It reads the game from inside OnMove to
+// check that observers are called without any lock held.
+type recordingObserver struct {
+	game  *Game
+	calls []string
+}
+
+func (r *recordingObserver) OnMove(m Move) {
+	r.calls = append(r.calls, fmt.Sprintf("move %v won=%v", m.From, r.game.IsWon()))
+}
+
+func (r *recordingObserver) OnUndo() { r.calls = append(r.calls, "undo") }
+func (r *recordingObserver) OnWin()  { r.calls = append(r.calls, "win") }
+
+func TestObserver(t *testing.T) {
+	game := endgame(t, []string{"sK"}, []string{"hK"})
+	observer := &recordingObserver{game: game}
+	game.Subscribe(observer)
+	other := &recordingObserver{game: game}
+	game.Subscribe(other)
+	game.Unsubscribe(other)
+
+	steps := []func() error{
+		func() error { return game.PlayToFoundation(Location{TABLEAU, 0, 0}) },
+		game.Undo,
+		game.Redo,
+		func() error { return game.PlayToFoundation(Location{TABLEAU, 1, 0}) },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Step %d: %v", i, err)
+		}
+	}
+	from0 := Location{TABLEAU, 0, 0}
+	from1 := Location{TABLEAU, 1, 0}
+	expected := []string{
+		fmt.Sprintf("move %v won=false", from0),
+		"undo",
+		fmt.Sprintf("move %v won=false", from0),
+		fmt.Sprintf("move %v won=true", from1),
+		"win",
+	}
+	if !reflect.DeepEqual(observer.calls, expected) {
+		t.Errorf("Observer calls -> %q; expected %q", observer.calls, expected)
+	}
+	if len(other.calls) != 0 {
+		t.Errorf("Unsubscribed observer calls -> %q; expected none", other.calls)
+	}
+	if clone := game.Clone(); len(clone.observers) != 0 {
+		t.Errorf("Clone().observers -> %d; expected 0", len(clone.observers))
+	}
+}
+
+// Counts the callbacks it receives.
+type countingObserver struct {
+	moves, undos, wins int
+}
+
+func (c *countingObserver) OnMove(m Move) { c.moves++ }
+func (c *countingObserver) OnUndo()       { c.undos++ }
+func (c *countingObserver) OnWin()        { c.wins++ }
+
+func TestObserverCounts(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	moves := game.LegalMoves()
+	valid := []Move{moves[1], moves[3]} // c8 t3>t6, then draw the ace of spades.
+	illegal := moves[0]
+	illegal.To.Category, illegal.To.Stack = FOUNDATION, 0
+	counter := new(countingObserver)
+	game.Subscribe(counter)
+	check := func(name string, moves, undos int) {
+		t.Helper()
+		if counter.moves != moves || counter.undos != undos || counter.wins != 0 {
+			t.Errorf("%s -> %+v; expected %d moves, %d undos", name, *counter, moves, undos)
+		}
+		*counter = countingObserver{}
+	}
+
+	// Search and rollback paths stay quiet.
+	game.EachSuccessor(func(m Move) bool { return true })
+	check("EachSuccessor", 0, 0)
+	if err := game.MoveManyAtomic([]Move{valid[0], illegal, valid[1]}); err == nil {
+		t.Error("MoveManyAtomic with an illegal move -> nil; expected error")
+	}
+	check("MoveManyAtomic with an illegal move", 0, 0)
+	if err := game.MoveManyAtomic(valid); err != nil {
+		t.Fatal("MoveManyAtomic:", err)
+	}
+	check("MoveManyAtomic", 2, 0)
+
+	// Restore takes back each move since the snapshot.
+	s := game.Snapshot()
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	*counter = countingObserver{}
+	game.Restore(s)
+	check("Restore", 0, 2)
+
+	// Playing a new move after an undo keeps the old line as branch 0, which
+	// splits off after the first move.
+	if err := game.Undo(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	*counter = countingObserver{}
+	if err := game.SwitchBranch(0); err != nil {
+		t.Fatal("SwitchBranch(0):", err)
+	}
+	check("SwitchBranch(0) past the split", 0, 1)
+	game.UndoAll()
+	*counter = countingObserver{}
+	if err := game.SwitchBranch(0); err != nil {
+		t.Fatal("SwitchBranch(0):", err)
+	}
+	check("SwitchBranch(0) before the split", 1, 0)
+	if game.UndoDepth() != 1 || game.RedoDepth() != 1 {
+		t.Errorf("SwitchBranch(0) before the split -> %d to undo, %d to redo; expected 1, 1", game.UndoDepth(), game.RedoDepth())
+	}
+}
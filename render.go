@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Choices for drawing a game as text.
+type RenderOptions struct {
+	Back string // Drawn in place of each facedown card.
+	Long bool   // Write cards by name, as in "seven of hearts", instead of by code.
+}
+
+// Options used when rendering without choosing any.
+var DefaultRenderOptions = RenderOptions{Back: "##"}
+
+// Get the label for a face-up card, or "--" for an empty pile.
+func (opts RenderOptions) label(card *Card) string {
+	switch {
+	case card == nil:
+		return "--"
+	case opts.Long:
+		return card.Name()
+	}
+	return card.Id()
+}
+
+// Draw the game as plain text, one line each for the stock and waste and the
+// foundations, then one line per tableau column from bottom to top. Unset
+// options fall back to DefaultRenderOptions.
+func (game *Game) RenderASCII(opts RenderOptions) string {
+	if opts.Back == "" {
+		opts.Back = DefaultRenderOptions.Back
+	}
+	sep := " "
+	if opts.Long {
+		sep = ", "
+	}
+
+	var b strings.Builder
+	pos := game.Stock.Pos
+	if pos > len(game.Stock.Stack) {
+		pos = len(game.Stock.Stack)
+	}
+	waste := (*Card)(nil)
+	if pos > 0 {
+		waste = game.Stock.Stack[pos-1]
+	}
+	b.WriteString("Stock: ")
+	if pos < len(game.Stock.Stack) {
+		b.WriteString(opts.Back)
+	} else {
+		b.WriteString("--")
+	}
+	b.WriteString("  Waste: " + opts.label(waste) + "\n")
+
+	piles := make([]string, len(game.foundations()))
+	for pile, stack := range game.foundations() {
+		piles[pile] = opts.label(top(stack))
+	}
+	b.WriteString("Foundations: " + strings.Join(piles, sep) + "\n")
+
+	for col, stack := range game.Tableau.Stacks[:game.ColumnCount()] {
+		cards := make([]string, len(stack))
+		for i, card := range stack {
+			if i < game.Tableau.Facedown[col] {
+				cards[i] = opts.Back
+			} else {
+				cards[i] = opts.label(card)
+			}
+		}
+		b.WriteString(strings.TrimRight(strconv.Itoa(col+1)+": "+strings.Join(cards, sep), " ") + "\n")
+	}
+	return b.String()
+}
+
+// Draw the game as plain text with the default options.
+func (game *Game) String() string {
+	return game.RenderASCII(DefaultRenderOptions)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderASCII(t *testing.T) {
+	game := endgame(t, []string{"sK", "hQ"}, []string{"hK"})
+	game.Tableau.Facedown[0] = 1
+
+	expected := "Stock: --  Waste: --\n" +
+		"Foundations: SQ CK HJ DK\n" +
+		"1: ## HQ\n" +
+		"2: HK\n" +
+		"3:\n4:\n5:\n6:\n7:\n"
+	if output := game.RenderASCII(RenderOptions{}); output != expected {
+		t.Errorf("RenderASCII(defaults) ->\n%s\nexpected:\n%s", output, expected)
+	}
+
+	// The same board with a card left to draw and another back face.
+	game.Stock.Stack = []*Card{game.Foundations[DIAMONDS][12]}
+	game.Foundations[DIAMONDS] = game.Foundations[DIAMONDS][:12]
+	expected = "Stock: []  Waste: --\n" +
+		"Foundations: SQ CK HJ DQ\n" +
+		"1: [] HQ\n" +
+		"2: HK\n" +
+		"3:\n4:\n5:\n6:\n7:\n"
+	if output := game.RenderASCII(RenderOptions{Back: "[]"}); output != expected {
+		t.Errorf("RenderASCII(Back: []) ->\n%s\nexpected:\n%s", output, expected)
+	}
+
+	game.Stock.Pos = 1
+	expected = "Stock: --  Waste: king of diamonds\n" +
+		"Foundations: queen of spades, king of clubs, jack of hearts, queen of diamonds\n" +
+		"1: ##, queen of hearts\n" +
+		"2: king of hearts\n" +
+		"3:\n4:\n5:\n6:\n7:\n"
+	if output := game.RenderASCII(RenderOptions{Long: true}); output != expected {
+		t.Errorf("RenderASCII(Long) ->\n%s\nexpected:\n%s", output, expected)
+	}
+}
+
+func TestGameString(t *testing.T) {
+	output := NewDeal(1).String()
+	for _, header := range []string{"Stock: ##", "Waste: --", "Foundations: -- -- -- --", "\n1: ", "\n7: ## ## ## ## ## ##"} {
+		if !strings.Contains(output, header) {
+			t.Errorf("String -> missing %q in:\n%s", header, output)
+		}
+	}
+
+	// A zero game or one with a stray stock position still prints.
+	game := new(Game)
+	game.Stock.Pos = 3
+	if output := game.String(); !strings.HasPrefix(output, "Stock: --  Waste: --\n") {
+		t.Errorf("String on an empty game ->\n%s", output)
+	}
+}
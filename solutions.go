@@ -3,6 +3,10 @@ package main
 
 import (
 	"container/heap"
+	"context"
+	"fmt"
+	"log"
+	"time"
 )
 
 // An IntHeap is a min-heap of ints.
@@ -56,5 +60,310 @@ func (s *SortedMoveSets) Push(x []*Move) {
 }
 
 func (s *SortedMoveSets) Pop() []*Move {
-	return s.sets.Pop().([]*Move)
+	return heap.Pop(&s.sets).([]*Move)
+}
+
+// How strongly the solver favors positions closer to a win over shorter move
+// sequences, weighing the cards left to play and the cards left to uncover.
+// Zero weights search breadth first.
+type solveWeights struct {
+	remaining, hidden int
+}
+
+// The solver scores a position as its depth plus 3 times the cards left to
+// play and to uncover. This is a deliberate departure from ordering by depth
+// plus RemainingToWin: that bound is admissible, so it would find the
+// shortest wins, but it explores close to breadth first and runs out of
+// positions on most deals. The weighted score can overestimate, so solutions
+// may be longer than needed, in exchange for reaching a win in far fewer
+// positions.
+var solveWeight = solveWeights{remaining: 3, hidden: 3}
+
+// A searchNode is a position in the solver's search, linked to the position it
+// was reached from so that nodes share their common move prefixes.
+type searchNode struct {
+	move   *Move
+	parent *searchNode
+	depth  int
+	score  int
+}
+
+// Get the moves leading from the start of the search to the node.
+func (n *searchNode) path() []*Move {
+	moves := make([]*Move, n.depth)
+	for ; n.move != nil; n = n.parent {
+		moves[n.depth-1] = n.move
+	}
+	return moves
+}
+
+// A nodeHeap is a min-heap of search nodes ordered by score.
+type nodeHeap []*searchNode
+
+func (h nodeHeap) Len() int {
+	return len(h)
+}
+func (h nodeHeap) Less(i, j int) bool {
+	return h[i].score < h[j].score
+}
+func (h nodeHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *nodeHeap) Push(x any) {
+	*h = append(*h, x.(*searchNode))
+}
+
+func (h *nodeHeap) Pop() any {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[:n-1]
+	return x
+}
+
+// Count the cards not yet on the foundations. Each needs at least one move,
+// so it's a lower bound on the moves left to win. The solver doesn't use it
+// as a bound; see solveWeight for how it's weighted instead.
+func (game *Game) RemainingToWin() int {
+	left := 52 * game.decks()
+	for _, stack := range game.foundations() {
+		left -= len(stack)
+	}
+	return left
+}
+
+// Score a position for the solver. Lower scores are searched first.
+func (game *Game) solveScore(depth int, weights solveWeights) int {
+	return depth + weights.remaining*game.RemainingToWin() + weights.hidden*game.HiddenCount()
+}
+
+// Check if a move is worth exploring. Moves that only shuffle cards between
+// columns without uncovering, emptying, or freeing anything are skipped.
+func (game *Game) worthSearching(m Move) bool {
+	switch {
+	case m.From.Category == STOCK, m.From.Category == FOUNDATION:
+		return true
+	case m.To.Category == STOCK:
+		return true
+	}
+	return game.hintScore(m) > 0
+}
+
+// Copy the game for searching. The redo history is dropped, since moves played
+// in the search would only set it aside as a branch.
+func (game *Game) searchClone() *Game {
+	work := game.Clone()
+	work.Moves.Next = nil
+	work.Moves.branches = nil
+	return work
+}
+
+// Positions the solver may explore when the caller doesn't choose a limit.
+const defaultSolveStates = 200000
+
+// Counts gathered by the solver while searching.
+type solveStats struct {
+	states    int // Positions taken off the frontier and expanded.
+	branches  int // Moves searched from expanded positions.
+	maxDepth  int // Most moves from the start to an expanded position.
+	tableHits int // Moves that led back to a position already seen.
+}
+
+// Statistics from one run of the solver, for comparing search settings.
+type SearchStats struct {
+	StatesExpanded int           // Positions taken off the frontier and expanded.
+	MaxDepth       int           // Most moves from the start to an expanded position.
+	Duration       time.Duration // Time spent searching.
+	TableHits      int           // Moves that led back to a position already seen.
+}
+
+// Search for a sequence of moves that wins the game, exploring at most
+// maxStates positions. The search is best-first, favoring positions with
+// fewer cards left to play or uncover. The search plans with every card the
+// game holds, facedown or in the stock, as in Thoughtful Solitaire, so a win
+// it finds may not be one a player could plan without seeing them. Returns
+// false if no solution was found; the game is left unchanged either way.
+func (game *Game) Solve(maxStates int) ([]Move, bool) {
+	solution, _, ok := game.solve(maxStates)
+	return solution, ok
+}
+
+// Solve the game like Solve, also reporting how the search went.
+func (game *Game) SolveWithStats(maxStates int) ([]Move, SearchStats, bool) {
+	began := time.Now()
+	solution, stats, ok := game.solve(maxStates)
+	return solution, SearchStats{stats.states, stats.maxDepth, time.Since(began), stats.tableHits}, ok
+}
+
+func (game *Game) solve(maxStates int) ([]Move, solveStats, bool) {
+	return game.solveWeighted(maxStates, solveWeight)
+}
+
+func (game *Game) solveWeighted(maxStates int, weights solveWeights) ([]Move, solveStats, bool) {
+	solution, stats, ok, _ := game.search(context.Background(), SolveOptions{MaxStates: maxStates}, weights)
+	return solution, stats, ok
+}
+
+// Settings for SolveContext and SolveResumable.
+type SolveOptions struct {
+	MaxStates int // Positions to explore; 0 means the default.
+	// Most positions to remember as already seen; 0 means no limit. Once
+	// full, the oldest are forgotten, capping memory at the cost of
+	// searching some positions again.
+	MaxTableEntries int
+}
+
+// How many positions the solver expands between checks for cancellation.
+const solveCheckInterval = 256
+
+// Search for a win like Solve with the given settings, stopping with the
+// context's error if it's canceled.
+func (game *Game) SolveContext(ctx context.Context, opts SolveOptions) ([]Move, bool, error) {
+	if opts.MaxStates == 0 {
+		opts.MaxStates = defaultSolveStates
+	}
+	solution, _, ok, err := game.search(ctx, opts, solveWeight)
+	return solution, ok, err
+}
+
+func (game *Game) search(ctx context.Context, opts SolveOptions, weights solveWeights) ([]Move, solveStats, bool, error) {
+	work := game.searchClone()
+	state := work.newSearch(opts, weights)
+	solution, ok, err := work.runSearch(ctx, opts, weights, state)
+	return solution, state.stats, ok, err
+}
+
+// A search in progress: what it's counted so far, the positions it's seen,
+// and the positions left to expand.
+type searchState struct {
+	stats    solveStats
+	seen     *StateSet
+	frontier *nodeHeap
+}
+
+// Begin a search from the game's position.
+func (game *Game) newSearch(opts SolveOptions, weights solveWeights) *searchState {
+	seen := NewBoundedStateSet(opts.MaxTableEntries)
+	seen.Add(game)
+	return &searchState{seen: seen, frontier: &nodeHeap{{score: game.solveScore(0, weights)}}}
+}
+
+// Expand positions from the search's frontier until a win is found, the
+// frontier runs out, MaxStates positions have been expanded in all, or ctx is
+// canceled. The game must be a search clone at the search's starting
+// position; it's left wherever the search stopped.
+func (game *Game) runSearch(ctx context.Context, opts SolveOptions, weights solveWeights, state *searchState) ([]Move, bool, error) {
+	work, stats, seen, frontier := game, &state.stats, state.seen, state.frontier
+	start := work.Snapshot()
+	for ; frontier.Len() > 0 && stats.states < opts.MaxStates; stats.states++ {
+		if stats.states%solveCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, false, err
+			}
+		}
+		node := heap.Pop(frontier).(*searchNode)
+		if node.depth > stats.maxDepth {
+			stats.maxDepth = node.depth
+		}
+
+		// Replay the node's moves from the start. Nodes found by the search
+		// only hold legal moves, but a resumed search's nodes come from a
+		// checkpoint, which may have been changed since it was written.
+		work.restore(start)
+		for _, m := range node.path() {
+			if err := work.play(*m); err != nil {
+				return nil, false, fmt.Errorf("Solver replayed an illegal move: %w", err)
+			}
+		}
+
+		if work.IsWon() {
+			path := node.path()
+			solution := make([]Move, len(path))
+			for i, m := range path {
+				solution[i] = *m
+			}
+			return solution, true, nil
+		}
+
+		for _, m := range work.LegalMoves() {
+			if !work.worthSearching(m) {
+				continue
+			}
+			stats.branches++
+			s := work.Snapshot()
+			work.play(m)
+			if seen.Add(work) {
+				move := m
+				heap.Push(frontier, &searchNode{
+					move:   &move,
+					parent: node,
+					depth:  node.depth + 1,
+					score:  work.solveScore(node.depth+1, weights),
+				})
+			} else {
+				stats.tableHits++
+			}
+			work.restore(s)
+		}
+	}
+	return nil, false, nil
+}
+
+// Find the fewest moves that turn every facedown tableau card face up,
+// searching at most maxStates positions breadth first. A cheaper target than
+// a full solve. Returns false if no such sequence was found.
+func (game *Game) MovesToRevealAll(maxStates int) (int, bool) {
+	work := game.searchClone()
+	start := work.Snapshot()
+	seen := NewStateSet()
+	seen.Add(work)
+	queue := []*searchNode{{}}
+
+	for states := 0; len(queue) > 0 && states < maxStates; states++ {
+		node := queue[0]
+		queue = queue[1:]
+
+		// Replay the node's moves from the start.
+		work.restore(start)
+		for _, m := range node.path() {
+			if err := work.play(*m); err != nil {
+				log.Panicln("Search replayed an illegal move:", err)
+			}
+		}
+		if work.HiddenCount() == 0 {
+			return node.depth, true
+		}
+
+		work.EachSuccessor(func(m Move) bool {
+			if seen.Add(work) {
+				queue = append(queue, &searchNode{move: &m, parent: node, depth: node.depth + 1})
+			}
+			return true
+		})
+	}
+	return 0, false
+}
+
+// Solve the game, then play the solution one move at a time, calling step
+// after each move so a UI can show it. Stops with the context's error if it's
+// canceled while solving or between moves.
+func (game *Game) AutoSolveDriver(ctx context.Context, step func(Move, *Game)) error {
+	solution, ok, err := game.SolveContext(ctx, SolveOptions{})
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("No solution found within %d positions.", defaultSolveStates)
+	}
+	for _, m := range solution {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := game.Apply(m); err != nil {
+			return err
+		}
+		step(m, game)
+	}
+	return nil
 }
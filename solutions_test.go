@@ -1,7 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"container/heap"
+	"context"
+	"errors"
 	"testing"
 )
 
@@ -75,3 +78,246 @@ func TestSortedMoveSets(t *testing.T) {
 		t.Log("Expected: ", expected)
 	}
 }
+
+// Build a game with the given tableau columns, listed bottom to top, and
+// every other card on the foundations.
+func endgame(t testing.TB, columns ...[]string) *Game {
+	t.Helper()
+	game := new(Game)
+	held := make(map[string]bool)
+	for col, codes := range columns {
+		for _, code := range codes {
+			card, err := ParseCard(code)
+			if err != nil {
+				t.Fatal("Setup error:", err)
+			}
+			game.Tableau.Stacks[col] = append(game.Tableau.Stacks[col], card)
+			held[card.Id()] = true
+		}
+	}
+	for _, card := range StandardCards() {
+		if !held[card.Id()] {
+			game.Foundations[card.Suit] = append(game.Foundations[card.Suit], card)
+		}
+	}
+	return game
+}
+
+// Replay a solution on a copy of the game and check that it wins.
+func assertSolves(t testing.TB, game *Game, solution []Move) {
+	t.Helper()
+	replay := game.Clone()
+	for i, m := range solution {
+		if err := replay.Apply(m); err != nil {
+			t.Fatalf("Solution move %d (%s): %v", i, describeMove(m), err)
+		}
+	}
+	if !replay.IsWon() {
+		t.Error("Solution did not win the game.")
+	}
+}
+
+func TestSolve(t *testing.T) {
+	game := endgame(t, []string{"sK", "hQ"}, []string{"hK", "sQ"})
+	game.Tableau.Facedown[0] = 1
+	before := gameJSON(t, game)
+
+	solution, ok := game.Solve(1000)
+	if !ok {
+		t.Fatal("Solve -> no solution; expected one.")
+	}
+	assertSolves(t, game, solution)
+	if output := gameJSON(t, game); !bytes.Equal(output, before) {
+		t.Error("Solve changed the game.")
+	}
+
+	// The two of hearts can't move off the facedown ace beneath it.
+	stuck := endgame(t, []string{"hA", "h2"})
+	stuck.Tableau.Facedown[0] = 1
+	if solution, ok := stuck.Solve(1000); ok {
+		t.Errorf("Solve -> %d moves; expected no solution.", len(solution))
+	}
+}
+
+func TestSolveContext(t *testing.T) {
+	game := endgame(t, []string{"sK", "hQ", "cQ"}, []string{"hK", "sQ"}, []string{"dK", "cK"})
+	game.Tableau.Facedown[0] = 1
+
+	// A tiny table forgets positions and searches some again, but still wins.
+	solution, ok, err := game.SolveContext(context.Background(), SolveOptions{MaxStates: 10000, MaxTableEntries: 2})
+	if err != nil || !ok {
+		t.Fatalf("SolveContext(2 table entries) -> %v, %v; expected a solution", ok, err)
+	}
+	assertSolves(t, game, solution)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, ok, err := game.SolveContext(ctx, SolveOptions{}); ok || !errors.Is(err, context.Canceled) {
+		t.Errorf("SolveContext(canceled) -> %v, %v; expected %v", ok, err, context.Canceled)
+	}
+}
+
+func TestSolveWithStats(t *testing.T) {
+	// The queens can go up in either order, so the search meets the same
+	// position twice.
+	game := endgame(t, []string{"sK", "hQ"}, []string{"hK", "sQ"})
+	solution, stats, ok := game.SolveWithStats(1000)
+	if !ok {
+		t.Fatal("SolveWithStats -> no solution; expected one.")
+	}
+	assertSolves(t, game, solution)
+	if stats.StatesExpanded == 0 || stats.Duration <= 0 {
+		t.Errorf("SolveWithStats -> %+v; expected states and duration", stats)
+	}
+	if stats.MaxDepth < len(solution) {
+		t.Errorf("SolveWithStats -> max depth %d; expected at least %d", stats.MaxDepth, len(solution))
+	}
+	if stats.TableHits == 0 {
+		t.Errorf("SolveWithStats -> %+v; expected table hits", stats)
+	}
+}
+
+func TestNewWinnableDeal(t *testing.T) {
+	game, seed, err := NewWinnableDeal(0, 20000)
+	if err != nil {
+		t.Fatal("NewWinnableDeal:", err)
+	}
+	if !bytes.Equal(stateJSON(t, game), stateJSON(t, NewDeal(seed))) {
+		t.Errorf("NewWinnableDeal returned a game not dealt from seed %d.", seed)
+	}
+	solution, ok := game.Solve(20000)
+	if !ok {
+		t.Fatal("Solve -> no solution for the winnable deal.")
+	}
+	assertSolves(t, game, solution)
+}
+
+func TestAutoSolveDriver(t *testing.T) {
+	game := endgame(t, []string{"sK", "hQ"}, []string{"hK", "sQ"})
+	game.Tableau.Facedown[0] = 1
+	steps := 0
+	err := game.AutoSolveDriver(context.Background(), func(m Move, g *Game) {
+		steps++
+		if last, _ := g.LastMove(); describeMove(last) != describeMove(m) {
+			t.Errorf("Step %d: last move %s; expected %s", steps, describeMove(last), describeMove(m))
+		}
+	})
+	if err != nil {
+		t.Fatal("AutoSolveDriver:", err)
+	}
+	if steps == 0 || steps != game.UndoDepth() {
+		t.Errorf("AutoSolveDriver called step %d times for %d moves", steps, game.UndoDepth())
+	}
+	if !game.IsWon() {
+		t.Error("AutoSolveDriver didn't win the game.")
+	}
+
+	// Canceling stops the driver between moves.
+	game = endgame(t, []string{"sK", "hQ"}, []string{"hK", "sQ"})
+	ctx, cancel := context.WithCancel(context.Background())
+	steps = 0
+	err = game.AutoSolveDriver(ctx, func(Move, *Game) {
+		steps++
+		cancel()
+	})
+	if !errors.Is(err, context.Canceled) || steps != 1 {
+		t.Errorf("Canceled AutoSolveDriver -> %v after %d steps; expected %v after 1", err, steps, context.Canceled)
+	}
+
+	// Canceling before the search finishes stops it without playing a move.
+	game = endgame(t, []string{"sK", "hQ"}, []string{"hK", "sQ"})
+	steps = 0
+	err = game.AutoSolveDriver(ctx, func(Move, *Game) { steps++ })
+	if !errors.Is(err, context.Canceled) || steps != 0 {
+		t.Errorf("AutoSolveDriver canceled before solving -> %v after %d steps; expected %v after 0", err, steps, context.Canceled)
+	}
+}
+
+func TestMovesToRevealAll(t *testing.T) {
+	// Playing the queen of hearts uncovers the last hidden card.
+	game := endgame(t, []string{"sK", "hQ"}, []string{"hK"})
+	game.Tableau.Facedown[0] = 1
+	before := gameJSON(t, game)
+	if moves, ok := game.MovesToRevealAll(1000); !ok || moves != 1 {
+		t.Errorf("MovesToRevealAll -> %d, %v; expected 1, true", moves, ok)
+	}
+	if output := gameJSON(t, game); !bytes.Equal(output, before) {
+		t.Error("MovesToRevealAll changed the game.")
+	}
+
+	game.Tableau.Facedown[0] = 0
+	if moves, ok := game.MovesToRevealAll(1000); !ok || moves != 0 {
+		t.Errorf("MovesToRevealAll with nothing hidden -> %d, %v; expected 0, true", moves, ok)
+	}
+
+	// The two of hearts can't move off the facedown ace beneath it.
+	stuck := endgame(t, []string{"hA", "h2"})
+	stuck.Tableau.Facedown[0] = 1
+	if moves, ok := stuck.MovesToRevealAll(1000); ok {
+		t.Errorf("MovesToRevealAll -> %d; expected no solution", moves)
+	}
+}
+
+func TestRemainingToWin(t *testing.T) {
+	if left := NewDeal(1).RemainingToWin(); left != 52 {
+		t.Errorf("RemainingToWin on a new deal -> %d; expected 52", left)
+	}
+	game := endgame(t, []string{"sK", "hQ"}, []string{"hK", "sQ"})
+	if left := game.RemainingToWin(); left != 4 {
+		t.Errorf("RemainingToWin with 4 cards out -> %d; expected 4", left)
+	}
+	game.Decks = 2
+	if left := game.RemainingToWin(); left != 56 {
+		t.Errorf("RemainingToWin with two decks -> %d; expected 56", left)
+	}
+}
+
+// A game partway through seed 2's solution, small enough to solve breadth first.
+func midgame(tb testing.TB) *Game {
+	tb.Helper()
+	game := NewDeal(2)
+	solution, ok := game.Solve(defaultSolveStates)
+	if !ok {
+		tb.Fatal("Setup error: no solution for seed 2")
+	}
+	if _, err := game.MoveMany(solution[:len(solution)-25]); err != nil {
+		tb.Fatal("Setup error:", err)
+	}
+	return game
+}
+
+func TestSolveHeuristic(t *testing.T) {
+	game := midgame(t)
+	_, informed, ok := game.solveWeighted(defaultSolveStates, solveWeight)
+	if !ok {
+		t.Fatal("solveWeighted(informed) -> no solution")
+	}
+	_, uninformed, _ := game.solveWeighted(informed.states*10, solveWeights{})
+	if informed.states >= uninformed.states {
+		t.Errorf("Informed search took %d states; expected fewer than uninformed %d", informed.states, uninformed.states)
+	}
+}
+
+func BenchmarkSolveHeuristic(b *testing.B) {
+	game := midgame(b)
+	// Breadth first, depth plus RemainingToWin, and the solver's own weights.
+	modes := []struct {
+		name    string
+		weights solveWeights
+	}{
+		{"breadth", solveWeights{}},
+		{"admissible", solveWeights{remaining: 1}},
+		{"weighted", solveWeight},
+	}
+	for _, mode := range modes {
+		weights := mode.weights
+		b.Run(mode.name, func(b *testing.B) {
+			states := 0
+			for i := 0; i < b.N; i++ {
+				_, stats, _ := game.solveWeighted(50000, weights)
+				states += stats.states
+			}
+			b.ReportMetric(float64(states)/float64(b.N), "states/op")
+		})
+	}
+}
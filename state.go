@@ -0,0 +1,66 @@
+package main
+
+// A GameState is a machine-readable view of a game's position as a player
+// sees it. Unlike SaveData, facedown cards are hidden and every pile is
+// listed in a fixed order:
+//
+//   - Tableau holds the columns from left to right, each listed bottom to
+//     top, with facedown cards shown as "??" unless the player knows them,
+//     in which case they're prefixed with KNOWN_FACEDOWN.
+//   - Foundations holds one pile per suit in the order spades, clubs, hearts,
+//     diamonds, repeated for each extra deck, each listed bottom to top.
+//   - Stock is the number of cards left to draw this pass.
+//   - Waste holds the drawn cards from bottom to top, so the last is playable.
+type GameState struct {
+	Tableau     []ColumnState     `json:"tableau"`
+	Foundations []FoundationState `json:"foundations"`
+	Stock       int               `json:"stock"`
+	Waste       []string          `json:"waste"`
+}
+
+type ColumnState struct {
+	Facedown int      `json:"facedown"`
+	Cards    []string `json:"cards"`
+}
+
+type FoundationState struct {
+	Suit  string   `json:"suit"`
+	Cards []string `json:"cards"`
+}
+
+// Get the card codes of a pile, hiding the first facedown cards.
+func pileCodes(stack []*Card, facedown int) []string {
+	codes := make([]string, len(stack))
+	for i, card := range stack {
+		if i < facedown {
+			codes[i] = "??"
+		} else {
+			codes[i] = card.Id()
+		}
+	}
+	return codes
+}
+
+// Describe the position as a player sees it.
+func (game *Game) Describe() GameState {
+	var state GameState
+	state.Tableau = make([]ColumnState, game.ColumnCount())
+	for col, stack := range game.Tableau.Stacks[:game.ColumnCount()] {
+		fd := game.Tableau.Facedown[col]
+		codes := pileCodes(stack, fd)
+		for i := 0; i < fd; i++ {
+			if game.IsKnown(col, i) {
+				codes[i] = KNOWN_FACEDOWN + stack[i].Id()
+			}
+		}
+		state.Tableau[col] = ColumnState{fd, codes}
+	}
+	state.Foundations = make([]FoundationState, len(game.foundations()))
+	for pile, stack := range game.foundations() {
+		state.Foundations[pile] = FoundationState{SuitName(pileSuit(pile)), pileCodes(stack, 0)}
+	}
+	pos := game.Stock.Pos
+	state.Stock = len(game.Stock.Stack) - pos
+	state.Waste = pileCodes(game.Stock.Stack[:pos], 0)
+	return state
+}
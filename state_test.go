@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDescribe(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := game.MoveTableau(3, 1, 6); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	expected := `{"tableau":[` +
+		`{"facedown":0,"cards":["D7"]},` +
+		`{"facedown":1,"cards":["??","H10"]},` +
+		`{"facedown":2,"cards":["??","??","H5"]},` +
+		`{"facedown":2,"cards":["??","??","S9"]},` +
+		`{"facedown":4,"cards":["??","??","??","??","S2"]},` +
+		`{"facedown":5,"cards":["??","??","??","??","??","S10"]},` +
+		`{"facedown":6,"cards":["??","??","??","??","??","??","D9","C8"]}],` +
+		`"foundations":[` +
+		`{"suit":"spades","cards":[]},` +
+		`{"suit":"clubs","cards":[]},` +
+		`{"suit":"hearts","cards":[]},` +
+		`{"suit":"diamonds","cards":[]}],` +
+		`"stock":23,"waste":["SA"]}`
+	output, err := json.Marshal(game.Describe())
+	if err != nil {
+		t.Fatal("Marshal error:", err)
+	}
+	if string(output) != expected {
+		t.Errorf("Describe != expected:\n\noutput:   %s\n\nexpected: %s", output, expected)
+	}
+}
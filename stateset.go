@@ -0,0 +1,103 @@
+package main
+
+// A StateSet holds distinct game positions, ignoring move history. Positions
+// are bucketed by Hash and confirmed equal before being treated as duplicates,
+// so hash collisions never merge different positions. Only each position's
+// key is kept, so games can be modified after being added.
+type StateSet struct {
+	buckets map[uint64][]string
+	size    int
+	limit   int      // Most positions to hold; 0 means no limit.
+	order   []string // Keys in the order added, oldest from head, when limited.
+	head    int
+}
+
+func NewStateSet() *StateSet {
+	return &StateSet{buckets: make(map[uint64][]string)}
+}
+
+// Make a set that holds at most limit positions, forgetting the oldest to
+// make room for new ones. A limit of 0 means no limit.
+func NewBoundedStateSet(limit int) *StateSet {
+	set := NewStateSet()
+	set.limit = limit
+	return set
+}
+
+// Add the game's position. Returns false if it was already in the set.
+func (set *StateSet) Add(game *Game) (added bool) {
+	return set.addKey(game.stateKey())
+}
+
+// Add a position by its stateKey.
+func (set *StateSet) addKey(key string) bool {
+	h := hashKey(key)
+	for _, k := range set.buckets[h] {
+		if k == key {
+			return false
+		}
+	}
+	set.buckets[h] = append(set.buckets[h], key)
+	set.size++
+	if set.limit > 0 {
+		set.order = append(set.order, key)
+		if set.size > set.limit {
+			set.evict()
+		}
+	}
+	return true
+}
+
+// Forget the oldest position in the set.
+func (set *StateSet) evict() {
+	key := set.order[set.head]
+	set.order[set.head] = ""
+	set.head++
+	if set.head*2 >= len(set.order) {
+		set.order = append(set.order[:0], set.order[set.head:]...)
+		set.head = 0
+	}
+
+	h := hashKey(key)
+	bucket := set.buckets[h]
+	for i, k := range bucket {
+		if k == key {
+			bucket = append(bucket[:i], bucket[i+1:]...)
+			break
+		}
+	}
+	if len(bucket) == 0 {
+		delete(set.buckets, h)
+	} else {
+		set.buckets[h] = bucket
+	}
+	set.size--
+}
+
+// Check if the game's position is in the set.
+func (set *StateSet) Contains(game *Game) bool {
+	key := game.stateKey()
+	for _, k := range set.buckets[hashKey(key)] {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// List the keys of the positions in the set, oldest first if it's limited.
+func (set *StateSet) keys() []string {
+	if set.limit > 0 {
+		return set.order[set.head:]
+	}
+	keys := make([]string, 0, set.size)
+	for _, bucket := range set.buckets {
+		keys = append(keys, bucket...)
+	}
+	return keys
+}
+
+// Get the number of distinct positions in the set.
+func (set *StateSet) Len() int {
+	return set.size
+}
@@ -0,0 +1,104 @@
+package main
+
+import "testing"
+
+func TestStateSet(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	set := NewStateSet()
+	if !set.Add(game) {
+		t.Error("Add(game) -> false; expected true for a new position")
+	}
+
+	// The same position reached another way isn't added again.
+	other := loadTestGame(t, "game.toml")
+	if err := other.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if err := other.Undo(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if !game.Equal(other) || game.Hash() != other.Hash() {
+		t.Error("Equal positions with different histories must be Equal with the same Hash.")
+	}
+	if set.Add(other) {
+		t.Error("Add(other) -> true; expected false for an equal position")
+	}
+	if !set.Contains(other) {
+		t.Error("Contains(other) -> false; expected true")
+	}
+
+	// A different position is added.
+	if err := other.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if game.Equal(other) {
+		t.Error("Equal after Draw -> true; expected false")
+	}
+	if set.Contains(other) {
+		t.Error("Contains(other) after Draw -> true; expected false")
+	}
+	if !set.Add(other) {
+		t.Error("Add(other) after Draw -> false; expected true")
+	}
+	if output := set.Len(); output != 2 {
+		t.Errorf("Len() -> %d; expected 2", output)
+	}
+}
+
+func TestBoundedStateSet(t *testing.T) {
+	set := NewBoundedStateSet(2)
+	games := []*Game{NewDeal(1), NewDeal(2), NewDeal(3)}
+	for _, game := range games {
+		set.Add(game)
+	}
+	if output := set.Len(); output != 2 {
+		t.Errorf("Len() -> %d; expected 2", output)
+	}
+	if set.Contains(games[0]) {
+		t.Error("Contains(oldest) -> true; expected it forgotten")
+	}
+	if !set.Contains(games[1]) || !set.Contains(games[2]) {
+		t.Error("Contains(newest) -> false; expected true")
+	}
+
+	// A forgotten position can be added again.
+	if !set.Add(games[0]) {
+		t.Error("Add(forgotten) -> false; expected true")
+	}
+}
+
+func TestStrategicEqual(t *testing.T) {
+	game := NewDeal(1)
+	if err := game.Draw(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+
+	// Reverse the cards left to draw.
+	other := game.Clone()
+	undealt := other.Stock.Stack[other.Stock.Pos:]
+	for i, j := 0, len(undealt)-1; i < j; i, j = i+1, j-1 {
+		undealt[i], undealt[j] = undealt[j], undealt[i]
+	}
+	if other.Equal(game) {
+		t.Error("Equal with the stock reversed -> true; expected false")
+	}
+	if !other.StrategicEqual(game) {
+		t.Error("StrategicEqual with the stock reversed -> false; expected true")
+	}
+
+	// The waste isn't part of the set.
+	stack := other.Stock.Stack
+	stack[0], stack[1] = stack[1], stack[0]
+	if other.StrategicEqual(game) {
+		t.Error("StrategicEqual with a different waste -> true; expected false")
+	}
+
+	// Nor is the tableau.
+	other = game.Clone()
+	if err := other.ForceMove(Location{TABLEAU, 0, 0}, Location{TABLEAU, 1, 0}, 1); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if other.StrategicEqual(game) {
+		t.Error("StrategicEqual with a different tableau -> true; expected false")
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Build a game from compact board text, as written by AsText. Handy for
+// setting up positions by hand. Each line is one of:
+//
+//	stock: H2 D9 | C4 SJ   drawn cards, then "|", then cards left to draw
+//	limit: 3               passes allowed through the stock; 0 is unlimited
+//	loop: 1                times the stock has been turned over
+//	foundations: SA S2 HA  cards put on their suit's foundation in order;
+//	                       "*" puts every card not listed elsewhere there
+//	D7 | C6 H5             a tableau column from bottom to top, with the
+//	                       facedown cards before the "|"
+//
+// Columns are filled left to right, "-" is an empty column, and columns not
+// listed are left empty, so every game built has all 7. Blank lines are
+// skipped. The board must hold a single full deck, as with Import, and the
+// game's other rules, such as the draw count, are left at their defaults.
+func BuildGame(spec string) (*Game, error) {
+	save := new(SaveData)
+	save.Foundations = make(map[string][]string)
+	var columns [][]string
+	var facedown []int
+	fill := false
+	for i, line := range strings.Split(spec, "\n") {
+		line = strings.TrimSpace(line)
+		key, rest, found := strings.Cut(line, ":")
+		switch {
+		case line == "":
+		case found && strings.TrimSpace(key) == "stock":
+			waste, stock, split := strings.Cut(rest, "|")
+			if !split {
+				waste, stock = "", rest
+			}
+			save.Stock.Stack = append(strings.Fields(waste), strings.Fields(stock)...)
+			save.Stock.Pos = len(strings.Fields(waste))
+		case found && (strings.TrimSpace(key) == "limit" || strings.TrimSpace(key) == "loop"):
+			n, err := strconv.Atoi(strings.TrimSpace(rest))
+			if err != nil {
+				return nil, fmt.Errorf("Line %d of board spec: %w", i+1, err)
+			}
+			if strings.TrimSpace(key) == "limit" {
+				save.Stock.Limit = n
+			} else {
+				save.Stock.Loop = n
+			}
+		case found && strings.TrimSpace(key) == "foundations":
+			for _, code := range strings.Fields(rest) {
+				if code == "*" {
+					fill = true
+					continue
+				}
+				card, err := ParseCard(code)
+				if err != nil {
+					return nil, fmt.Errorf("Line %d of board spec: %w", i+1, err)
+				}
+				name := SuitName(card.Suit)
+				save.Foundations[name] = append(save.Foundations[name], code)
+			}
+		case line == "-":
+			columns = append(columns, []string{})
+			facedown = append(facedown, 0)
+		default:
+			down, up, split := strings.Cut(line, "|")
+			if !split {
+				down, up = "", line
+			}
+			columns = append(columns, append(strings.Fields(down), strings.Fields(up)...))
+			facedown = append(facedown, len(strings.Fields(down)))
+		}
+	}
+	size := len(new(Game).Tableau.Stacks)
+	if len(columns) > size {
+		return nil, fmt.Errorf("Board spec has %d columns; max is %d.", len(columns), size)
+	}
+	for len(columns) < size {
+		columns = append(columns, []string{})
+		facedown = append(facedown, 0)
+	}
+	save.Tableau.Stacks, save.Tableau.Facedown = columns, facedown
+
+	if fill {
+		held := make(map[string]bool)
+		listed := [][]string{save.Stock.Stack}
+		listed = append(listed, columns...)
+		for _, codes := range save.Foundations {
+			listed = append(listed, codes)
+		}
+		for _, codes := range listed {
+			for _, code := range codes {
+				if card, err := ParseCard(code); err == nil {
+					held[card.Id()] = true
+				}
+			}
+		}
+		for _, card := range StandardCards() {
+			if !held[card.Id()] {
+				name := SuitName(card.Suit)
+				save.Foundations[name] = append(save.Foundations[name], card.Id())
+			}
+		}
+	}
+	return NewGameFromSave(save)
+}
+
+// Write the board as compact text that BuildGame reads back: the waste and
+// stock, the stock's pass limit and passes made if set, the foundations, then
+// one line per tableau column. Other rules and the move history aren't
+// written, and known facedown cards are written as plain facedown cards.
+func (game *Game) AsText() string {
+	var b strings.Builder
+	pos := game.Stock.Pos
+	b.WriteString(textLine([]string{"stock:"}, pileCodes(game.Stock.Stack[:pos], 0), []string{"|"}, pileCodes(game.Stock.Stack[pos:], 0)))
+	if game.Stock.Limit != 0 {
+		fmt.Fprintf(&b, "limit: %d\n", game.Stock.Limit)
+	}
+	if game.Stock.Loop != 0 {
+		fmt.Fprintf(&b, "loop: %d\n", game.Stock.Loop)
+	}
+	foundations := [][]string{{"foundations:"}}
+	for _, stack := range game.foundations() {
+		foundations = append(foundations, pileCodes(stack, 0))
+	}
+	b.WriteString(textLine(foundations...))
+	for col, stack := range game.Tableau.Stacks[:game.ColumnCount()] {
+		switch fd := game.Tableau.Facedown[col]; {
+		case len(stack) == 0:
+			b.WriteString("-\n")
+		case fd == 0:
+			b.WriteString(textLine(pileCodes(stack, 0)))
+		default:
+			b.WriteString(textLine(pileCodes(stack[:fd], 0), []string{"|"}, pileCodes(stack[fd:], 0)))
+		}
+	}
+	return b.String()
+}
+
+// Join groups of fields into one line of board text.
+func textLine(groups ...[]string) string {
+	var fields []string
+	for _, group := range groups {
+		fields = append(fields, group...)
+	}
+	return strings.Join(fields, " ") + "\n"
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBuildGame(t *testing.T) {
+	game, err := BuildGame(`
+		stock: HK DQ | CK
+		foundations: HA *
+		SK | HQ
+		-
+		DK SQ | CQ
+	`)
+	if err != nil {
+		t.Fatal("BuildGame:", err)
+	}
+
+	expected := new(SaveData)
+	expected.Stock.Pos = 2
+	expected.Stock.Stack = []string{"HK", "DQ", "CK"}
+	expected.Tableau.Stacks = [][]string{{"SK", "HQ"}, {}, {"DK", "SQ", "CQ"}, {}, {}, {}, {}}
+	expected.Tableau.Facedown = []int{1, 0, 2, 0, 0, 0, 0}
+	expected.Foundations = make(map[string][]string)
+	for _, card := range StandardCards() {
+		if card.Rank < QUEEN {
+			name := SuitName(card.Suit)
+			expected.Foundations[name] = append(expected.Foundations[name], card.Id())
+		}
+	}
+	if save := game.Export(); !reflect.DeepEqual(save, expected) {
+		t.Errorf("BuildGame(...).Export() -> %+v; expected %+v", save, expected)
+	}
+
+	// A spec without "*" must list the whole deck.
+	if _, err := BuildGame("SA | HK"); err == nil {
+		t.Error("BuildGame(partial deck) -> nil; expected error")
+	}
+	if _, err := BuildGame("foundations: *\n-\n-\n-\n-\n-\n-\n-\n-"); err == nil {
+		t.Error("BuildGame(8 columns) -> nil; expected error")
+	}
+}
+
+func TestAsText(t *testing.T) {
+	game, err := BuildGame("stock: HQ | DK\nfoundations: *\nSK | HK")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	expected := "stock: HQ | DK\n" +
+		"foundations: SA S2 S3 S4 S5 S6 S7 S8 S9 S10 SJ SQ " +
+		"CA C2 C3 C4 C5 C6 C7 C8 C9 C10 CJ CQ CK " +
+		"HA H2 H3 H4 H5 H6 H7 H8 H9 H10 HJ " +
+		"DA D2 D3 D4 D5 D6 D7 D8 D9 D10 DJ DQ\n" +
+		"SK | HK\n-\n-\n-\n-\n-\n-\n"
+	if output := game.AsText(); output != expected {
+		t.Errorf("AsText() ->\n%s\nexpected:\n%s", output, expected)
+	}
+
+	// Random positions survive a round trip.
+	for seed := int64(1); seed <= 5; seed++ {
+		game := NewDeal(seed)
+		game.PlayRandom(rand.New(rand.NewSource(seed)), 40)
+		text := game.AsText()
+		built, err := BuildGame(text)
+		if err != nil {
+			t.Errorf("BuildGame(AsText()) for seed %d -> %v\n%s", seed, err, text)
+			continue
+		}
+		if !built.Equal(game) {
+			t.Errorf("BuildGame(AsText()) for seed %d gave a different position:\n%s\nexpected:\n%s", seed, built.AsText(), text)
+		}
+	}
+
+	// The stock's pass limit and passes made survive too.
+	game = NewDealPreset(VEGAS_DRAW_3, 2)
+	for game.Stock.Pos < len(game.Stock.Stack) {
+		if err := game.Draw(); err != nil {
+			t.Fatal("Setup error:", err)
+		}
+	}
+	if err := game.RecycleStock(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	text := game.AsText()
+	built, err := BuildGame(text)
+	if err != nil {
+		t.Fatalf("BuildGame(AsText()) for Vegas -> %v\n%s", err, text)
+	}
+	if built.Stock.Limit != 3 || built.Stock.Loop != 1 || !built.Equal(game) {
+		t.Errorf("BuildGame(AsText()) for Vegas -> limit %d, loop %d:\n%s\nexpected limit 3, loop 1:\n%s", built.Stock.Limit, built.Stock.Loop, built.AsText(), text)
+	}
+}
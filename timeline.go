@@ -0,0 +1,45 @@
+package main
+
+import "fmt"
+
+// A TimelineFrame is the position right after one move of a game's history.
+type TimelineFrame struct {
+	Move Move
+	Save *SaveData
+}
+
+// Export the position after each move played so far, oldest first, for tools
+// that animate a replay. The frames are rebuilt by replaying the history from
+// the start, so the game itself isn't touched. Moves left to redo aren't
+// included. Returns an error if the history can't be replayed.
+func (game *Game) Timeline() ([]TimelineFrame, error) {
+	history := game.Moves.Prev
+	if len(history) == 0 {
+		return nil, nil
+	}
+	replay := new(Game)
+	if err := replay.Import(game.startSave()); err != nil {
+		return nil, fmt.Errorf("Timeline start failed to import: %w", err)
+	}
+	frames := make([]TimelineFrame, len(history))
+	for i, m := range history {
+		if err := replay.Apply(*m); err != nil {
+			return nil, fmt.Errorf("Timeline replay failed at move %d: %w", i, err)
+		}
+		frames[i] = TimelineFrame{*m, replay.Export()}
+	}
+	return frames, nil
+}
+
+// Get the position before the first move of the history as save data. It's
+// found by undoing every move on a clone, then cached for as long as the
+// history starts with the same move.
+func (game *Game) startSave() *SaveData {
+	first := game.Moves.Prev[0]
+	if game.start.save == nil || game.start.first != first {
+		work := game.Clone()
+		work.UndoAll()
+		game.start.first, game.start.save = first, work.Export()
+	}
+	return game.start.save
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestTimeline(t *testing.T) {
+	game := NewDeal(3)
+	if frames, err := game.Timeline(); frames != nil || err != nil {
+		t.Errorf("Timeline with no moves -> %d frames, %v; expected none, nil", len(frames), err)
+	}
+	played := game.PlayRandom(rand.New(rand.NewSource(3)), 30)
+	if played < 2 {
+		t.Fatal("Setup error: only", played, "moves played")
+	}
+	before := stateJSON(t, game)
+	frames, err := game.Timeline()
+	if err != nil {
+		t.Fatal("Timeline:", err)
+	}
+	if len(frames) != played {
+		t.Fatalf("Timeline -> %d frames; expected %d", len(frames), played)
+	}
+	if output := stateJSON(t, game); !bytes.Equal(output, before) {
+		t.Errorf("Timeline changed the game:\n\noutput:   %s\n\nexpected: %s", output, before)
+	}
+	last, err := CanonicalJSON(frames[len(frames)-1].Save)
+	if err != nil {
+		t.Fatal("CanonicalJSON:", err)
+	}
+	expected, err := CanonicalJSON(game.Export())
+	if err != nil {
+		t.Fatal("CanonicalJSON:", err)
+	}
+	if !bytes.Equal(last, expected) {
+		t.Errorf("Timeline last frame != Export:\n\noutput:   %s\n\nexpected: %s", last, expected)
+	}
+
+	// Taking back a move drops its frame; each remaining frame is unchanged.
+	if err := game.Undo(); err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	shorter, err := game.Timeline()
+	if err != nil {
+		t.Fatal("Timeline after Undo:", err)
+	}
+	if len(shorter) != played-1 {
+		t.Fatalf("Timeline after Undo -> %d frames; expected %d", len(shorter), played-1)
+	}
+	for i, frame := range shorter {
+		output, _ := CanonicalJSON(frame.Save)
+		original, _ := CanonicalJSON(frames[i].Save)
+		if !bytes.Equal(output, original) {
+			t.Errorf("Timeline after Undo frame %d != frame before Undo", i)
+		}
+	}
+
+	// A start that can't be imported is reported, not a panic.
+	game.start.first, game.start.save = game.Moves.Prev[0], new(SaveData)
+	if frames, err := game.Timeline(); frames != nil || !errors.Is(err, ErrDeckIncomplete) {
+		t.Errorf("Timeline from a bad start -> %d frames, %v; expected none, %v", len(frames), err, ErrDeckIncomplete)
+	}
+}
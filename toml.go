@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format strings, such as card codes, as an inline TOML array.
+func tomlStrings(codes []string) string {
+	quoted := make([]string, len(codes))
+	for i, code := range codes {
+		quoted[i] = fmt.Sprintf("%q", code)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// Write save data as TOML laid out for hand editing: the stock, tableau,
+// foundations, and any meta sections in that order, foundations in suit order, and card
+// lists as inline arrays with one tableau column per line. Card codes are
+// normalized as in CanonicalJSON. A move history comes last, with its
+// starting position's sections under [history.start].
+func (save *SaveData) WriteTOML(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	if err := save.writeTOML(b, ""); err != nil {
+		return err
+	}
+	return b.Flush()
+}
+
+// Write save data as TOML with its sections nested under prefix, which is
+// empty or a table name ending in ".".
+func (save *SaveData) writeTOML(b *bufio.Writer, prefix string) error {
+	piles, err := save.foundationPiles()
+	if err != nil {
+		return err
+	}
+	stock, pos, err := save.stockPile()
+	if err != nil {
+		return err
+	}
+	if stock, err = canonicalCodes(stock); err != nil {
+		return err
+	}
+	stacks := make([][]string, len(save.Tableau.Stacks))
+	for i, codes := range save.Tableau.Stacks {
+		if stacks[i], err = canonicalCodes(codes); err != nil {
+			return err
+		}
+	}
+	foundations := make([][]string, len(piles))
+	for i, codes := range piles {
+		if foundations[i], err = canonicalCodes(codes); err != nil {
+			return err
+		}
+	}
+
+	if prefix != "" {
+		fmt.Fprintf(b, "[%s]\n", strings.TrimSuffix(prefix, "."))
+	}
+	if save.Decks > 1 {
+		fmt.Fprintf(b, "decks = %d\n", save.Decks)
+	}
+	if save.Seed != nil {
+		fmt.Fprintf(b, "seed = %d\n", *save.Seed)
+	}
+	if save.DrawCount > 1 {
+		fmt.Fprintf(b, "drawcount = %d\n", save.DrawCount)
+	}
+	if v := save.Variant; v != (Variant{}) {
+		fmt.Fprintf(b, "variant = { order = %d, manualflip = %t }\n", v.Order, v.ManualFlip)
+	}
+	if save.Decks > 1 || save.Seed != nil || save.DrawCount > 1 || save.Variant != (Variant{}) {
+		b.WriteString("\n")
+	}
+
+	fmt.Fprintf(b, "[%sstock]\n", prefix)
+	fmt.Fprintf(b, "  limit = %d\n", save.Stock.Limit)
+	fmt.Fprintf(b, "  loop  = %d\n", save.Stock.Loop)
+	fmt.Fprintf(b, "  pos   = %d\n", pos)
+	fmt.Fprintf(b, "  stack = %s\n", tomlStrings(stock))
+
+	fmt.Fprintf(b, "\n[%stableau]\n", prefix)
+	b.WriteString("  stacks = [\n")
+	for i, codes := range stacks {
+		sep := ","
+		if i == len(stacks)-1 {
+			sep = ""
+		}
+		fmt.Fprintf(b, "    %s%s\n", tomlStrings(codes), sep)
+	}
+	b.WriteString("  ]\n")
+	facedown := make([]string, len(save.Tableau.Facedown))
+	for i, fd := range save.Tableau.Facedown {
+		facedown[i] = fmt.Sprint(fd)
+	}
+	fmt.Fprintf(b, "  facedown = [%s]\n", strings.Join(facedown, ", "))
+
+	fmt.Fprintf(b, "\n[%sfoundations]\n", prefix)
+	for i, name := range foundationNames(save.decks()) {
+		fmt.Fprintf(b, "  %-8s = %s\n", name, tomlStrings(foundations[i]))
+	}
+
+	if meta := save.Meta; !meta.Saved.IsZero() || meta.Version != "" || meta.Title != "" {
+		fmt.Fprintf(b, "\n[%smeta]\n", prefix)
+		if !meta.Saved.IsZero() {
+			fmt.Fprintf(b, "  saved   = %s\n", meta.Saved.Format(time.RFC3339Nano))
+		}
+		if meta.Version != "" {
+			fmt.Fprintf(b, "  version = %q\n", meta.Version)
+		}
+		if meta.Title != "" {
+			fmt.Fprintf(b, "  title   = %q\n", meta.Title)
+		}
+	}
+
+	if h := save.History; h != nil {
+		if h.Start == nil {
+			return errorOf(ErrInvalidHistory, "Move history has no starting position.")
+		}
+		fmt.Fprintf(b, "\n[%shistory]\n", prefix)
+		fmt.Fprintf(b, "  moves = %s\n", tomlStrings(h.Moves))
+		if len(h.Redo) > 0 {
+			fmt.Fprintf(b, "  redo  = %s\n", tomlStrings(h.Redo))
+		}
+		b.WriteString("\n")
+		return h.Start.writeTOML(b, prefix+"history.start.")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+func TestWriteTOML(t *testing.T) {
+	game := loadTestGame(t, "game.toml")
+	var b bytes.Buffer
+	if err := game.Export().WriteTOML(&b); err != nil {
+		t.Fatal("WriteTOML:", err)
+	}
+	expected, err := os.ReadFile("game_pretty.toml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	if output := b.Bytes(); !bytes.Equal(output, expected) {
+		t.Errorf("WriteTOML != game_pretty.toml:\n\noutput:\n%s\n\nexpected:\n%s", output, expected)
+	}
+
+	// The output loads back into the same game.
+	save := new(SaveData)
+	if err := toml.Unmarshal(b.Bytes(), save); err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	var loaded Game
+	if err := loaded.Import(save); err != nil {
+		t.Fatal("Import:", err)
+	}
+	if output, expected := stateJSON(t, &loaded), stateJSON(t, game); !bytes.Equal(output, expected) {
+		t.Errorf("Loaded TOML != original:\n\noutput:   %s\n\nexpected: %s", output, expected)
+	}
+
+	// Seeds are written as a top level key.
+	seed := int64(42)
+	save.Seed = &seed
+	b.Reset()
+	if err := save.WriteTOML(&b); err != nil {
+		t.Fatal("WriteTOML with seed:", err)
+	}
+	if !bytes.HasPrefix(b.Bytes(), []byte("seed = 42\n\n[stock]\n")) {
+		t.Errorf("WriteTOML with seed -> %q...; expected seed first", b.Bytes()[:20])
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+// Rules that differ between solitaire variants. The zero value is standard
+// Klondike.
+type Variant struct {
+	Order RankOrder
+	// Leave a facedown card uncovered on the tableau facedown until it's
+	// turned over with Flip, rather than turning it over automatically.
+	ManualFlip bool
+}
+
+// Check if card can be played onto a foundation whose top card is top.
+// A nil top means the foundation is empty.
+func (v Variant) CanFound(top, card *Card) bool {
+	if card == nil {
+		return false
+	}
+	if top == nil {
+		return card.Rank == v.Order.Lowest()
+	}
+	next, ok := top.Rank.Next(v.Order)
+	return ok && card.Suit == top.Suit && card.Rank == next
+}
+
+// Check that cards form a foundation pile of the suit: the lowest rank, then
+// each next rank in turn, with no repeats or gaps.
+func (v Variant) isValidFoundationStack(cards []*Card, suit CardSuit) error {
+	var seen [UNKNOWN_RANK + 1]bool
+	for i, card := range cards {
+		switch {
+		case card == nil:
+			return errorOf(ErrInvalidFoundation, "Missing card at index %d.", i)
+		case card.Suit != suit:
+			return errorOf(ErrInvalidFoundation, "Suit mismatch: %s at index %d.", card.Id(), i)
+		case v.CanFound(top(cards[:i]), card):
+			seen[card.Rank] = true
+			continue
+		case i == 0:
+			return errorOf(ErrInvalidFoundation, "Pile must start with a %s, not %s.", RankName(v.Order.Lowest()), card.Id())
+		case seen[card.Rank]:
+			return errorOf(ErrInvalidFoundation, "Repeated %s at index %d.", card.Id(), i)
+		default:
+			return errorOf(ErrInvalidFoundation, "Gap: %s follows %s at index %d.", card.Id(), cards[i-1].Id(), i)
+		}
+	}
+	return nil
+}
+
+// Check if card can be built onto a tableau stack whose top card is top.
+// A nil top means the stack is empty.
+func (v Variant) CanBuild(top, card *Card) bool {
+	if card == nil {
+		return false
+	}
+	if top == nil {
+		return card.Rank == v.Order.Highest()
+	}
+	prev, ok := top.Rank.Prev(v.Order)
+	return ok && card.Color != top.Color && card.Color != UNKNOWN_COLOR && card.Rank == prev
+}
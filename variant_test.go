@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCanFound(t *testing.T) {
+	low := Variant{Order: ACE_LOW}
+	high := Variant{Order: ACE_HIGH}
+	cards := mustParseCards(t, "hA", "h2", "hK", "s2")
+	ace, two, king, spade := cards[0], cards[1], cards[2], cards[3]
+
+	tests := []struct {
+		variant   Variant
+		top, card *Card
+		expected  bool
+	}{
+		{low, nil, ace, true},
+		{low, nil, two, false},
+		{low, ace, two, true},
+		{low, ace, spade, false},
+		{low, king, ace, false},
+		// Legal only when aces are high.
+		{high, nil, ace, false},
+		{high, nil, two, true},
+		{high, king, ace, true},
+		{high, ace, two, false},
+	}
+	for i, test := range tests {
+		if output := test.variant.CanFound(test.top, test.card); output != test.expected {
+			t.Errorf("Test %d: CanFound -> %v; expected %v", i, output, test.expected)
+		}
+	}
+}
+
+func TestCanBuild(t *testing.T) {
+	low := Variant{Order: ACE_LOW}
+	high := Variant{Order: ACE_HIGH}
+	cards := mustParseCards(t, "sA", "hK", "sK", "h2", "cQ", "??")
+	ace, king, blackKing, two, queen, unknown := cards[0], cards[1], cards[2], cards[3], cards[4], cards[5]
+
+	tests := []struct {
+		variant   Variant
+		top, card *Card
+		expected  bool
+	}{
+		{low, nil, king, true},
+		{low, nil, ace, false},
+		{low, king, queen, true},
+		{low, blackKing, queen, false},
+		{low, two, ace, true},
+		{low, ace, king, false},
+		{low, king, unknown, false},
+		// Legal only when aces are high.
+		{high, nil, ace, true},
+		{high, nil, king, false},
+		{high, ace, king, true},
+		{high, two, ace, false},
+	}
+	for i, test := range tests {
+		if output := test.variant.CanBuild(test.top, test.card); output != test.expected {
+			t.Errorf("Test %d: CanBuild -> %v; expected %v", i, output, test.expected)
+		}
+	}
+}
+
+func TestIsValidFoundationStack(t *testing.T) {
+	low := Variant{Order: ACE_LOW}
+	high := Variant{Order: ACE_HIGH}
+	tests := []struct {
+		variant Variant
+		codes   []string
+		message string // Empty if the pile is valid.
+	}{
+		{low, nil, ""},
+		{low, []string{"hA", "h2", "h3"}, ""},
+		{high, []string{"h2", "h3"}, ""},
+		{low, []string{"hA", "s2"}, "Suit mismatch: S2 at index 1."},
+		{low, []string{"h2", "h3"}, "Pile must start with a ace, not H2."},
+		{high, []string{"hA"}, "Pile must start with a two, not HA."},
+		{low, []string{"hA", "h3"}, "Gap: H3 follows HA at index 1."},
+		{low, []string{"hA", "h2", "h2"}, "Repeated H2 at index 2."},
+		{low, []string{"hA", "h2", "h3", "h2"}, "Repeated"},
+		{low, []string{"hA", "h3", "h2"}, "Gap: H3 follows HA at index 1."},
+		{low, []string{"hA", "h?"}, "Gap: H? follows HA at index 1."},
+	}
+	for i, test := range tests {
+		cards := mustParseCards(t, test.codes...)
+		err := test.variant.isValidFoundationStack(cards, HEARTS)
+		switch {
+		case test.message == "" && err != nil:
+			t.Errorf("Test %d: isValidFoundationStack(%v) -> %v; expected nil", i, test.codes, err)
+		case test.message == "":
+		case !errors.Is(err, ErrInvalidFoundation) || !strings.Contains(err.Error(), test.message):
+			t.Errorf("Test %d: isValidFoundationStack(%v) -> %v; expected %q", i, test.codes, err, test.message)
+		}
+	}
+	if err := low.isValidFoundationStack([]*Card{nil}, HEARTS); !errors.Is(err, ErrInvalidFoundation) {
+		t.Errorf("isValidFoundationStack([nil]) -> %v; expected %v", err, ErrInvalidFoundation)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Subset of the XML save schema used by Windows solitaire collections.
+type xmlGame struct {
+	GameType string    `xml:"GameType,attr"`
+	Passes   int       `xml:"Passes,attr"`
+	Piles    []xmlPile `xml:"Pile"`
+}
+
+type xmlPile struct {
+	Role  string    `xml:"Role,attr"`
+	Cards []xmlCard `xml:"Card"`
+}
+
+type xmlCard struct {
+	Suit     string `xml:"Suit,attr"`
+	Rank     string `xml:"Rank,attr"`
+	FaceDown bool   `xml:"FaceDown,attr"`
+}
+
+// Get the card code for an XML card. Facedown cards without a suit and rank
+// are unknown.
+func (c xmlCard) code() (string, error) {
+	card := Card{Rank: UNKNOWN_RANK, Suit: UNKNOWN_SUIT}
+	if c.Suit == "" && c.Rank == "" {
+		if !c.FaceDown {
+			return "", errors.New("Face-up card is missing its suit and rank.")
+		}
+		return card.Id(), nil
+	}
+
+	suit := strings.ToLower(c.Suit)
+	for s := SPADES; s < UNKNOWN_SUIT; s++ {
+		if suit == SuitName(s) {
+			card.Suit = s
+		}
+	}
+	if card.Suit == UNKNOWN_SUIT {
+		return "", fmt.Errorf("Unrecognized card suit %q.", c.Suit)
+	}
+
+	rank := strings.ToLower(c.Rank)
+	for r := ACE; r < UNKNOWN_RANK; r++ {
+		if rank == RankName(r) {
+			card.Rank = r
+		}
+	}
+	if n, err := strconv.Atoi(rank); err == nil && n >= 1 && n <= 13 {
+		card.Rank = CardRank(n - 1)
+	}
+	if card.Rank == UNKNOWN_RANK {
+		return "", fmt.Errorf("Unrecognized card rank %q.", c.Rank)
+	}
+
+	return card.Id(), nil
+}
+
+// Get the card codes of an XML pile from bottom to top.
+func (p xmlPile) codes() ([]string, error) {
+	codes := make([]string, len(p.Cards))
+	for i, c := range p.Cards {
+		code, err := c.code()
+		if err != nil {
+			return nil, fmt.Errorf("%s pile card %d: %w", p.Role, i, err)
+		}
+		codes[i] = code
+	}
+	return codes, nil
+}
+
+// Load a Klondike game from an XML solitaire save. Piles are listed bottom to
+// top, so the last card of the stock pile is the next to be drawn.
+func LoadXML(r io.Reader) (*SaveData, error) {
+	var doc xmlGame
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(doc.GameType, "klondike") {
+		return nil, fmt.Errorf("Unsupported game type %q. Only Klondike is supported.", doc.GameType)
+	}
+
+	save := new(SaveData)
+	save.Stock.Limit = doc.Passes
+	save.Foundations = map[string][]string{
+		"spades":   {},
+		"clubs":    {},
+		"hearts":   {},
+		"diamonds": {},
+	}
+	var stock, waste []string
+	for _, pile := range doc.Piles {
+		codes, err := pile.codes()
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(pile.Role) {
+		case "stock":
+			stock = append(stock, codes...)
+		case "waste":
+			waste = append(waste, codes...)
+		case "tableau":
+			facedown := 0
+			for facedown < len(pile.Cards) && pile.Cards[facedown].FaceDown {
+				facedown++
+			}
+			for _, c := range pile.Cards[facedown:] {
+				if c.FaceDown {
+					return nil, errors.New("Tableau pile has a facedown card above a face-up card.")
+				}
+			}
+			save.Tableau.Stacks = append(save.Tableau.Stacks, codes)
+			save.Tableau.Facedown = append(save.Tableau.Facedown, facedown)
+		case "foundation":
+			if len(pile.Cards) == 0 {
+				continue
+			}
+			card, _ := ParseCard(codes[0])
+			if card.Suit == UNKNOWN_SUIT {
+				return nil, errors.New("Foundation pile has an unknown card.")
+			}
+			key := SuitName(card.Suit)
+			if len(save.Foundations[key]) > 0 {
+				return nil, fmt.Errorf("Found more than one %s foundation.", key)
+			}
+			save.Foundations[key] = codes
+		default:
+			return nil, fmt.Errorf("Unrecognized pile role %q.", pile.Role)
+		}
+	}
+
+	// The waste comes first in the stock stack, followed by the stock from
+	// its top down.
+	save.Stock.Pos = len(waste)
+	save.Stock.Stack = waste
+	for i := len(stock) - 1; i >= 0; i-- {
+		save.Stock.Stack = append(save.Stock.Stack, stock[i])
+	}
+	if save.Stock.Stack == nil {
+		save.Stock.Stack = []string{}
+	}
+
+	return save, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadXML(t *testing.T) {
+	file, err := os.Open("game.xml")
+	if err != nil {
+		t.Fatal("Setup error:", err)
+	}
+	defer file.Close()
+	save, err := LoadXML(file)
+	if err != nil {
+		t.Fatal("LoadXML:", err)
+	}
+	var game Game
+	if err := game.Import(save); err != nil {
+		t.Fatal("Import:", err)
+	}
+	expected := stateJSON(t, loadTestGame(t, "game.toml"))
+	if output := stateJSON(t, &game); !bytes.Equal(output, expected) {
+		t.Errorf("LoadXML(game.xml) != game.toml:\n\noutput:   %s\n\nexpected: %s", output, expected)
+	}
+}
+
+func TestLoadXMLPiles(t *testing.T) {
+	input := `<SolitaireGame GameType="klondike">
+  <Pile Role="Stock"><Card Suit="Clubs" Rank="2" FaceDown="true"/><Card Suit="Clubs" Rank="3" FaceDown="true"/></Pile>
+  <Pile Role="Waste"><Card Suit="Hearts" Rank="Four"/><Card Suit="Hearts" Rank="Five"/></Pile>
+  <Pile Role="Foundation"><Card Suit="Spades" Rank="Ace"/><Card Suit="Spades" Rank="Two"/></Pile>
+  <Pile Role="Tableau"><Card FaceDown="true"/><Card Suit="Diamonds" Rank="King"/></Pile>
+</SolitaireGame>`
+	save, err := LoadXML(strings.NewReader(input))
+	if err != nil {
+		t.Fatal("LoadXML:", err)
+	}
+	if output := strings.Join(save.Stock.Stack, " "); output != "H4 H5 C3 C2" {
+		t.Errorf("Stock.Stack = %s; expected H4 H5 C3 C2", output)
+	}
+	if save.Stock.Pos != 2 {
+		t.Errorf("Stock.Pos = %d; expected 2", save.Stock.Pos)
+	}
+	if output := strings.Join(save.Foundations["spades"], " "); output != "SA S2" {
+		t.Errorf("Spades foundation = %s; expected SA S2", output)
+	}
+	if output := strings.Join(save.Tableau.Stacks[0], " "); output != "?? DK" {
+		t.Errorf("Tableau column 0 = %s; expected ?? DK", output)
+	}
+	if save.Tableau.Facedown[0] != 1 {
+		t.Errorf("Tableau column 0 has %d facedown; expected 1", save.Tableau.Facedown[0])
+	}
+
+	bad := []string{
+		`<SolitaireGame GameType="FreeCell"></SolitaireGame>`,
+		`<SolitaireGame GameType="Klondike"><Pile Role="Reserve"/></SolitaireGame>`,
+		`<SolitaireGame GameType="Klondike"><Pile Role="Tableau"><Card Suit="Stars" Rank="Ace"/></Pile></SolitaireGame>`,
+		`<SolitaireGame GameType="Klondike"><Pile Role="Tableau"><Card/></Pile></SolitaireGame>`,
+		`<SolitaireGame GameType="Klondike"><Pile Role="Tableau"><Card Suit="Spades" Rank="Ace"/><Card FaceDown="true"/></Pile></SolitaireGame>`,
+		`<SolitaireGame GameType="Klondike"><Pile Role="Foundation"><Card Suit="Spades" Rank="Ace"/></Pile><Pile Role="Foundation"><Card Suit="Spades" Rank="Two"/></Pile></SolitaireGame>`,
+		`<SolitaireGame`,
+	}
+	for i, b := range bad {
+		if _, err := LoadXML(strings.NewReader(b)); err == nil {
+			t.Errorf("Expected error from bad[%d].", i)
+		}
+	}
+}